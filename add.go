@@ -0,0 +1,60 @@
+package main
+
+import "flag"
+
+// runAdd implements the "add" subcommand, which adds a snippet, or edits or
+// deletes the last one with -edit_last or -delete_last. It's the default if no
+// subcommand is given. Its flags are the global flags, so that they can be
+// given either before or after "add".
+func runAdd(args []string) error {
+	fs := newFlagSet("add")
+	flag.VisitAll(func(f *flag.Flag) {
+		if f.Name == "notebook" {
+			return
+		}
+		fs.Var(cmdlineValue{f.Value, f.Name}, f.Name, f.Usage)
+	})
+	notebookFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return usageErrorf("add: unexpected arguments %q", fs.Args())
+	}
+	if len(args) != 0 {
+		// Flags given after "add" haven't been checked yet, and -dir or
+		// -notebook may have changed which config files apply.
+		if err := setup(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case *editLast:
+		return editLastSnippet()
+	case *deleteLast:
+		return deleteLastSnippet()
+	default:
+		return run()
+	}
+}
+
+// cmdlineValue is a flag.Value for a global flag given after a subcommand,
+// which records that it was set on the command line, so that it takes
+// precedence over the notebook config (see loadNotebookConfig).
+type cmdlineValue struct {
+	flag.Value
+	name string
+}
+
+func (v cmdlineValue) Set(value string) error {
+	cmdlineFlags[v.name] = true
+	return v.Value.Set(value)
+}
+
+// IsBoolFlag reports whether the wrapped flag is a boolean flag, which can be
+// given without a value, e.g. "-dedup".
+func (v cmdlineValue) IsBoolFlag() bool {
+	b, ok := v.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/saser/snip/snip"
+)
+
+// attachmentPrefix starts the continuation line referring to a file attached
+// to a snippet with -attach, e.g. "  attachment: screenshot.png".
+const attachmentPrefix = "attachment: "
+
+// attachmentName returns the name to store the file at src under in the
+// directory of the snippet file at path, i.e. its base name, with a number
+// added if that name is already taken, e.g. "screenshot-2.png".
+func attachmentName(path, src string) (string, error) {
+	dir := filepath.Dir(path)
+	base := filepath.Base(src)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = stem + "-" + strconv.Itoa(i) + ext
+		}
+		if strings.HasPrefix(name, snip.DirSnippetFile) {
+			continue
+		}
+		_, err := os.Lstat(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			return name, nil
+		} else if err != nil {
+			return "", fmt.Errorf("attach %s: %w", src, err)
+		}
+	}
+}
+
+// copyAttachment copies the file at src into the directory of the snippet file
+// at path, under the given name (see [attachmentName]).
+func copyAttachment(path, src, name string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("attach %s: %w", src, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), fs.FileMode(dirMode)); err != nil {
+		return fmt.Errorf("attach %s: %w", src, err)
+	}
+	if err := writeFile(filepath.Join(filepath.Dir(path), name), data); err != nil {
+		return fmt.Errorf("attach %s: %w", src, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAttach(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		existing string
+		wantErr  bool
+	}{
+		{
+			name:     "added",
+			existing: "--- Wednesday Nov 20 2024 in UTC ---\n09:00 | earlier\n",
+		},
+		{
+			name:     "write rejected",
+			existing: "--- Wednesday Nov 20 2024 in UTC ---\n10:30 | later\n",
+			wantErr:  true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			base := t.TempDir()
+			src := filepath.Join(t.TempDir(), "screenshot.png")
+			if err := os.WriteFile(src, []byte("png"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(base, "2024-11-20.txt")
+			if err := os.WriteFile(path, []byte(tt.existing), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			setClock(t, time.Date(2024, time.November, 20, 10, 0, 0, 0, time.Local))
+			setFlag(t, "dir", base)
+			setFlag(t, "attach", src)
+			setFlag(t, "strict_order", "true")
+
+			_, err := writeSnippets([][]byte{[]byte("see screenshot")})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeSnippets() = %v, want error: %v", err, tt.wantErr)
+			}
+			_, statErr := os.Stat(filepath.Join(base, "screenshot.png"))
+			if gotAttachment := statErr == nil; gotAttachment == tt.wantErr {
+				t.Errorf("attachment exists: %v, want %v (stat error: %v)", gotAttachment, !tt.wantErr, statErr)
+			}
+			if tt.wantErr {
+				if got, err := os.ReadFile(path); err != nil || string(got) != tt.existing {
+					t.Errorf("snippet file = %q, %v; want it unchanged", got, err)
+				}
+			}
+		})
+	}
+}
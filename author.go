@@ -0,0 +1,48 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// authorRE matches the author of a snippet, like "@alice", at the end of a
+// line of snippet text (see -attribute). Like tags, it must be preceded by
+// whitespace, so that e.g. email addresses aren't interpreted as authors.
+var authorRE = regexp.MustCompile(`(?m)(?:^|\s)@([\p{L}\p{N}._-]+)$`)
+
+// authorNameRE matches a valid author name, without the leading '@'.
+var authorNameRE = regexp.MustCompile(`^[\p{L}\p{N}._-]+$`)
+
+// resolveAuthor returns the author to attribute snippets to with -attribute:
+// -author, or if that is empty, the current user according to $USER (or
+// %USERNAME% on Windows).
+func resolveAuthor() (string, error) {
+	name := strings.TrimPrefix(cmp.Or(*author, os.Getenv("USER"), os.Getenv("USERNAME")), "@")
+	if name == "" {
+		return "", usageErrorf("-attribute requires -author, since $USER is not set")
+	}
+	if !authorNameRE.MatchString(name) {
+		return "", usageErrorf("invalid -author %q: authors may only contain letters, digits, '.', '-' and '_'", name)
+	}
+	return name, nil
+}
+
+// appendAuthor appends the author to text as " @name".
+func appendAuthor(text []byte, name string) []byte {
+	return fmt.Appendf(text, " @%s", name)
+}
+
+// extractAuthor returns the name (without the leading '@') of the author of a
+// snippet with the given text, i.e. the last "@name" ending a line (before the
+// word count added by -word_count, if any), or the empty string if there is
+// none.
+func extractAuthor(text string) string {
+	m := authorRE.FindAllStringSubmatch(wordCountRE.ReplaceAllString(text, ""), -1)
+	if len(m) == 0 {
+		return ""
+	}
+	return m[len(m)-1][1]
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestExtractAuthor(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want string
+	}{
+		{text: "rotated the certificates", want: ""},
+		{text: "rotated the certificates @alice", want: "alice"},
+		{text: "rotated the certificates @alice (3 words)", want: "alice"},
+		{text: "rotated the certificates (3 words) @alice", want: "alice"},
+		{text: "emailed bob@example.com", want: ""},
+		{text: "asked @bob about it\n  and he said no @alice", want: "alice"},
+	} {
+		if got := extractAuthor(tt.text); got != tt.want {
+			t.Errorf("extractAuthor(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
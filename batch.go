@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// runBatch adds each non-blank line in the file given by -batch as a separate
+// snippet. All snippets are added in a single write, so that either all or none
+// of them end up in the snippet file.
+func runBatch() error {
+	contents, err := os.ReadFile(*batch)
+	if err != nil {
+		return fmt.Errorf("read batch file: %w", err)
+	}
+	var texts [][]byte
+	for _, line := range bytes.Split(contents, []byte{'\n'}) {
+		if line = bytes.TrimSpace(line); len(line) != 0 {
+			texts = append(texts, line)
+		}
+	}
+	if len(texts) == 0 {
+		return errEmptySnippet
+	}
+	n, err := writeSnippets(texts)
+	if err != nil {
+		return err
+	}
+	// Keep stdout clean for -print_path.
+	if !*toStdout && !*dryRun && !*printPath {
+		fmt.Printf("Added %d snippets.\n", n)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunBatch(t *testing.T) {
+	now := time.Date(2024, time.November, 20, 9, 15, 0, 0, time.Local)
+	setClock(t, now)
+	base := t.TempDir()
+	setFlag(t, "dir", base)
+	file := filepath.Join(t.TempDir(), "batch.txt")
+	if err := os.WriteFile(file, []byte("\nfirst  \n\n  \t\n\tsecond\t\r\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	setFlag(t, "batch", file)
+	if err := runBatch(); err != nil {
+		t.Fatalf("runBatch failed: %v", err)
+	}
+	if got, want := readSnippets(t, now), "09:15 | first\n09:15 | second\n"; got != want {
+		t.Errorf("snippets:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRunBatchEmpty(t *testing.T) {
+	setFlag(t, "dir", t.TempDir())
+	file := filepath.Join(t.TempDir(), "batch.txt")
+	if err := os.WriteFile(file, []byte("\n  \n\t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	setFlag(t, "batch", file)
+	if err := runBatch(); !errors.Is(err, errEmptySnippet) {
+		t.Errorf("runBatch() with only blank lines = %v, want %v", err, errEmptySnippet)
+	}
+}
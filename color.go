@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/saser/snip/snip"
+)
+
+// ANSI escape codes for colorizing the output of the read commands; see
+// -color.
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiTimestamp = "\x1b[36m"   // cyan
+	ansiMatch     = "\x1b[1;31m" // bold red
+)
+
+// colorFlag registers the -color flag on the flag set of a read subcommand,
+// overriding the global -color flag.
+func colorFlag(fs *flag.FlagSet) {
+	fs.Func("color", "When to colorize the output: \"auto\", \"always\", or \"never\". Overrides the global -color flag.", func(s string) error {
+		*color = s
+		return validateColor()
+	})
+}
+
+// validateColor checks the value of -color.
+func validateColor() error {
+	switch *color {
+	case "auto", "always", "never":
+		return nil
+	default:
+		return usageErrorf("invalid -color %q: must be one of \"auto\", \"always\", or \"never\"", *color)
+	}
+}
+
+// useColor reports whether to colorize output according to -color. With
+// "auto", output is colorized if stdout is a terminal, unless $NO_COLOR is set
+// (see https://no-color.org).
+func useColor() bool {
+	switch *color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize wraps s in the ANSI escape code and a reset.
+func colorize(s, code string) string {
+	return code + s + ansiReset
+}
+
+// colorSnippet colorizes a snippet line: the timestamp prefix, if any, and the
+// matches of re in the text, if re isn't nil. Continuation lines are
+// colorized line by line, so that no escape code spans a line break.
+func colorSnippet(line string, re *regexp.Regexp) string {
+	var prefix, sep string
+	if p, text, ok := splitTimestamp(line); ok {
+		prefix, sep, line = p, line[len(p):len(line)-len(text)], text
+	}
+	if re != nil {
+		line = re.ReplaceAllStringFunc(line, func(m string) string {
+			var parts []string
+			for _, part := range strings.Split(m, "\n") {
+				if part != "" {
+					part = colorize(part, ansiMatch)
+				}
+				parts = append(parts, part)
+			}
+			return strings.Join(parts, "\n")
+		})
+	}
+	if prefix == "" && sep == "" {
+		return line
+	}
+	return colorize(prefix, ansiTimestamp) + sep + line
+}
+
+// colorSnippetFile colorizes the contents of a snippet file, consisting of the
+// header (which may be empty) and the rest: the header and divider lines are
+// bold, and the timestamp prefixes are colorized.
+func colorSnippetFile(header, rest []byte) []byte {
+	var b bytes.Buffer
+	for _, line := range strings.SplitAfter(string(header), "\n") {
+		content := strings.TrimSuffix(line, "\n")
+		if content != "" {
+			b.WriteString(colorize(content, ansiBold))
+		}
+		b.WriteString(line[len(content):])
+	}
+	for _, line := range strings.SplitAfter(string(rest), "\n") {
+		content := strings.TrimSuffix(line, "\n")
+		newline := line[len(content):]
+		switch {
+		case snip.IsDivider([]byte(content)):
+			content = colorize(content, ansiBold)
+		case !strings.HasPrefix(content, snip.ContinuationIndent):
+			content = colorSnippet(content, nil)
+		}
+		b.WriteString(content + newline)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUseColor(t *testing.T) {
+	// Redirect stdout to a file, which isn't a terminal.
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := os.Stdout
+	os.Stdout = f
+	t.Cleanup(func() {
+		os.Stdout = previous
+		f.Close()
+	})
+	for _, tt := range []struct {
+		name    string
+		color   string
+		noColor string
+		want    bool
+	}{
+		{name: "always", color: "always", want: true},
+		{name: "always with NO_COLOR", color: "always", noColor: "1", want: true},
+		{name: "never", color: "never", want: false},
+		{name: "auto with NO_COLOR", color: "auto", noColor: "1", want: false},
+		{name: "auto without a terminal", color: "auto", want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, "color", tt.color)
+			t.Setenv("NO_COLOR", tt.noColor)
+			if got := useColor(); got != tt.want {
+				t.Errorf("useColor() with -color %s and NO_COLOR=%q = %v, want %v", tt.color, tt.noColor, got, tt.want)
+			}
+		})
+	}
+}
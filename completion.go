@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// dateFlags are the names of all flags, global or for subcommands, that accept
+// a date. They are completed with the dates of existing snippet files.
+var dateFlags = []string{"append_to", "date", "since", "until"}
+
+// runCompletion implements the "completion" subcommand, which prints a shell
+// completion script.
+func runCompletion(args []string) error {
+	fs := newFlagSet("completion")
+	notebookFlag(fs)
+	dates := fs.Bool("dates", false, "Instead of a completion script, print the dates of all existing snippet files, one per line. This is used by the completion scripts.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dates {
+		return printSnippetDates()
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("completion: expected exactly one shell (bash, zsh, or fish), got %d arguments", fs.NArg())
+	}
+
+	var flags []*flag.Flag
+	flag.VisitAll(func(f *flag.Flag) { flags = append(flags, f) })
+	names := subcommandNames()
+
+	var script string
+	switch shell := fs.Arg(0); shell {
+	case "bash":
+		script = bashCompletion(flags, names)
+	case "zsh":
+		// zsh can use bash completion scripts through bashcompinit.
+		script = "autoload -U +X bashcompinit && bashcompinit\n" + bashCompletion(flags, names)
+	case "fish":
+		script = fishCompletion(flags, names)
+	default:
+		return usageErrorf("completion: unsupported shell %q: must be bash, zsh, or fish", shell)
+	}
+	if _, err := fmt.Print(script); err != nil {
+		return fmt.Errorf("completion: %w", err)
+	}
+	return nil
+}
+
+// printSnippetDates prints the dates of all snippet files, one per line. For
+// weekly and monthly snippet files, that's the first day of the week or month,
+// so that it can be completed as a -date.
+func printSnippetDates() error {
+	paths, err := snippetFiles()
+	if err != nil {
+		return fmt.Errorf("completion: %w", err)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	for _, path := range paths {
+		if date, ok := snip.FileDate(path); ok {
+			fmt.Fprintln(w, date.Format(time.DateOnly))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("completion: %w", err)
+	}
+	return nil
+}
+
+func bashCompletion(flags []*flag.Flag, subcommandNames []string) string {
+	var flagNames []string
+	for _, f := range flags {
+		flagNames = append(flagNames, "-"+f.Name)
+	}
+	var dateCases []string
+	for _, name := range dateFlags {
+		dateCases = append(dateCases, "-"+name)
+	}
+	return fmt.Sprintf(`_snip() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	local prev="${COMP_WORDS[COMP_CWORD-1]}"
+	case "$prev" in
+	%s)
+		COMPREPLY=($(compgen -W "$(snip completion -dates 2>/dev/null)" -- "$cur"))
+		return
+		;;
+	esac
+	if [[ "$cur" == -* ]]; then
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+		return
+	fi
+	COMPREPLY=($(compgen -W "%s" -- "$cur"))
+}
+complete -F _snip snip
+`, strings.Join(dateCases, "|"), strings.Join(flagNames, " "), strings.Join(subcommandNames, " "))
+}
+
+func fishCompletion(flags []*flag.Flag, subcommandNames []string) string {
+	var b strings.Builder
+	b.WriteString("complete -c snip -f\n")
+	fmt.Fprintf(&b, "complete -c snip -n __fish_use_subcommand -a %s\n", fishQuote(strings.Join(subcommandNames, " ")))
+	for _, f := range flags {
+		fmt.Fprintf(&b, "complete -c snip -o %s -d %s\n", f.Name, fishQuote(f.Usage))
+	}
+	for _, name := range dateFlags {
+		fmt.Fprintf(&b, "complete -c snip -o %s -x -a '(snip completion -dates 2>/dev/null)'\n", name)
+	}
+	return b.String()
+}
+
+// fishQuote quotes s as a single-quoted fish string.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/saser/snip/snip"
+)
+
+// configFileName is the name of the config file in the base directory.
+const configFileName = "config"
+
+// parseConfig parses the contents of a config file. Each non-blank line is of
+// the form "key = value", where key is the name of a global flag and value is
+// its value. Values can be double-quoted, e.g. to preserve trailing spaces.
+// Lines starting with '#' are comments.
+func parseConfig(contents []byte) (map[string]string, error) {
+	config := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(contents))
+	for lineno := 1; sc.Scan(); lineno++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, usageErrorf("line %d: expected \"key = value\", got %q", lineno, line)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value %s: %w", lineno, value, err)
+			}
+			value = unquoted
+		}
+		config[key] = value
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// notebookConfigFileName is the name of the config file in a notebook's
+// directory, which sets defaults for that notebook only.
+const notebookConfigFileName = ".snipconfig"
+
+// notebookConfigKeys are the flags that can be set in a notebook config. These
+// are the flags about how snippets are formatted and filed, which is what
+// makes sense to differ between notebooks.
+var notebookConfigKeys = []string{
+	"attribute",
+	"bullets",
+	"day_start",
+	"granularity",
+	"header_style",
+	"header_format",
+	"header_prefix",
+	"include_header",
+	"include_time",
+	"layout",
+	"multiline",
+	"no_timestamp",
+	"prepend",
+	"separator",
+	"subheaders",
+	"tz_display",
+	"word_count",
+}
+
+var (
+	// cmdlineFlags are the names of the global flags explicitly set on the
+	// command line, which take precedence over the config files.
+	cmdlineFlags map[string]bool
+	// globalConfig is the contents of the config file in the base directory.
+	globalConfig map[string]string
+	// notebookConfigSet are the names of the global flags set from the
+	// currently loaded notebook config.
+	notebookConfigSet []string
+)
+
+// readConfig reads and parses the config file at path. A missing config file
+// is treated as empty.
+func readConfig(path string) (map[string]string, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	config, err := parseConfig(contents)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// loadConfig reads the config file from the base directory and uses it to set
+// the values of any global flags that weren't explicitly set on the command
+// line. That way, flags override the config file, which overrides the built-in
+// defaults. A missing config file is silently ignored. Then the notebook
+// config is loaded on top; see loadNotebookConfig.
+//
+// It's called again when flags given after "add" change the base directory,
+// in which case the values from the previous config are reset first.
+func loadConfig() error {
+	if err := checkBaseDir(); err != nil {
+		return err
+	}
+	base, err := baseDir()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	path := filepath.Join(base, configFileName)
+	config, err := readConfig(path)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	// Only look at which flags are set the first time, since setting them
+	// from a config file counts as setting them too. Flags given after "add"
+	// are recorded as they're parsed; see cmdlineValue.
+	if cmdlineFlags == nil {
+		cmdlineFlags = make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { cmdlineFlags[f.Name] = true })
+	}
+	for key := range globalConfig {
+		if cmdlineFlags[key] {
+			continue
+		}
+		if err := flag.Set(key, flag.Lookup(key).DefValue); err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+	}
+	for key, value := range config {
+		if flag.Lookup(key) == nil {
+			return usageErrorf("load config: %s: unknown key %q", path, key)
+		}
+		if cmdlineFlags[key] {
+			continue
+		}
+		if err := flag.Set(key, value); err != nil {
+			return usageErrorf("load config: %s: %w", path, err)
+		}
+	}
+	globalConfig = config
+	return loadNotebookConfig()
+}
+
+// loadNotebookConfig reads the config file of the current notebook, if any,
+// and uses it to set the values of the global flags in notebookConfigKeys that
+// weren't explicitly set on the command line. That way, the resolution order
+// is: flags, then the notebook config, then the global config, then the
+// built-in defaults. A missing notebook config is silently ignored.
+//
+// It's called again when a subcommand's -notebook flag changes the notebook,
+// in which case the values from the previous notebook config are reset first.
+func loadNotebookConfig() error {
+	for _, key := range notebookConfigSet {
+		value, ok := globalConfig[key]
+		if !ok {
+			value = flag.Lookup(key).DefValue
+		}
+		if err := flag.Set(key, value); err != nil {
+			return fmt.Errorf("load notebook config: %w", err)
+		}
+	}
+	notebookConfigSet = nil
+	// An invalid notebook name is reported by validateFlags.
+	if *notebook == "" || snip.CheckFileName(*notebook) != nil {
+		return nil
+	}
+
+	dir, err := snippetDir()
+	if err != nil {
+		return fmt.Errorf("load notebook config: %w", err)
+	}
+	path := filepath.Join(dir, notebookConfigFileName)
+	config, err := readConfig(path)
+	if err != nil {
+		return fmt.Errorf("load notebook config: %w", err)
+	}
+	for key, value := range config {
+		if !slices.Contains(notebookConfigKeys, key) {
+			return usageErrorf("load notebook config: %s: unsupported key %q: must be one of %s", path, key, strings.Join(notebookConfigKeys, ", "))
+		}
+		if cmdlineFlags[key] {
+			continue
+		}
+		if err := flag.Set(key, value); err != nil {
+			return usageErrorf("load notebook config: %s: %w", path, err)
+		}
+		notebookConfigSet = append(notebookConfigSet, key)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	base := t.TempDir()
+	if err := os.WriteFile(filepath.Join(base, configFileName), []byte("separator = \" :: \"\nword_count = true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	nb := filepath.Join(base, "work")
+	if err := os.Mkdir(nb, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nb, notebookConfigFileName), []byte("separator = \" > \"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "global config", want: "09:15 :: two words (2 words)\n"},
+		{name: "notebook config over global config", args: []string{"-notebook", "work"}, want: "09:15 > two words (2 words)\n"},
+		{name: "flags over notebook config", args: []string{"-notebook", "work", "-separator", " ~ ", "-word_count=false"}, want: "09:15 ~ two words\n"},
+		{name: "other notebooks", args: []string{"-notebook", "home"}, want: "09:15 :: two words (2 words)\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-dir", base, "-stdout", "-at", "09:15", "-m", "two words"}, tt.args...)
+			stdout, stderr, code := runSnip(t, args...)
+			if code != 0 {
+				t.Fatalf("snip %q failed with exit code %d: %s", args, code, stderr)
+			}
+			if stdout != tt.want {
+				t.Errorf("snip %q printed %q, want %q", args, stdout, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	got, err := parseConfig([]byte("# comment\n\ndir = /tmp/snip\n  include_time = \"15:04 | \"  \n"))
+	if err != nil {
+		t.Fatalf("parseConfig failed: %v", err)
+	}
+	if len(got) != 2 || got["dir"] != "/tmp/snip" || got["include_time"] != "15:04 | " {
+		t.Errorf("parseConfig() = %q, want dir and include_time", got)
+	}
+	if _, err := parseConfig([]byte("no equals sign\n")); err == nil {
+		t.Error("parseConfig() with an invalid line succeeded, want error")
+	}
+}
+
+func TestConfigAfterAdd(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(first, configFileName), []byte("separator = \" :: \"\nword_count = true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(second, configFileName), []byte("separator = \" > \"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	nb := filepath.Join(second, "work")
+	if err := os.Mkdir(nb, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nb, notebookConfigFileName), []byte("word_count = true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "-dir after add",
+			args: []string{"-dir", first, "add", "-dir", second},
+			want: "09:15 > two words\n",
+		},
+		{
+			name: "-dir and -notebook after add",
+			args: []string{"-dir", first, "add", "-dir", second, "-notebook", "work"},
+			want: "09:15 > two words (2 words)\n",
+		},
+		{
+			name: "flags after add over the config",
+			args: []string{"-dir", first, "add", "-dir", second, "-separator", " ~ "},
+			want: "09:15 ~ two words\n",
+		},
+		{
+			name: "flags before add over the config",
+			args: []string{"-dir", first, "-separator", " ~ ", "add", "-dir", second},
+			want: "09:15 ~ two words\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-stdout", "-at", "09:15", "-m", "two words"}, tt.args...)
+			stdout, stderr, code := runSnip(t, args...)
+			if code != 0 {
+				t.Fatalf("snip %q failed with exit code %d: %s", args, code, stderr)
+			}
+			if stdout != tt.want {
+				t.Errorf("snip %q printed %q, want %q", args, stdout, tt.want)
+			}
+		})
+	}
+
+	// The new base directory is checked like the one given before "add".
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, code := runSnip(t, "-dir", first, "add", "-dir", file, "-m", "text")
+	if code == 0 || !strings.Contains(stderr, "is not a directory") {
+		t.Errorf("snip add -dir with a file: exit code %d, stderr %q; want the error for a base directory that is a file", code, stderr)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// runCount implements the "count" subcommand, which prints the number of
+// snippets per day in a date range, and the total.
+func runCount(args []string) error {
+	fs := newFlagSet("count")
+	notebookFlag(fs)
+	since := fs.String("since", "", "First date (YYYY-MM-DD, inclusive) to count snippets for. Defaults to the first date with snippets.")
+	until := fs.String("until", "", "Last date (YYYY-MM-DD, inclusive) to count snippets for. Defaults to the last date with snippets.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	r, err := parseDateRange(*since, *until)
+	if err != nil {
+		return fmt.Errorf("count snippets: %w", err)
+	}
+
+	paths, err := snippetFiles()
+	if err != nil {
+		return fmt.Errorf("count snippets: %w", err)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	total := 0
+	for _, path := range paths {
+		date, ok := snip.FileDate(path)
+		if !ok || !r.contains(date) {
+			continue
+		}
+		contents, err := readSnippetFile(path)
+		if err != nil {
+			return fmt.Errorf("count snippets: %w", err)
+		}
+		n := len(snippetLines(contents))
+		total += n
+		fmt.Fprintf(w, "%s: %d\n", date.Format(time.DateOnly), n)
+	}
+	fmt.Fprintf(w, "total: %d\n", total)
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("count snippets: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseDateFlag parses the value of a date-accepting flag with the given name,
+// such as -append_to, as a date in the local timezone. See parseDate for the
+// accepted values.
+func parseDateFlag(name, value string) (time.Time, error) {
+	t, err := parseDate(value, dayOf(clock()))
+	if errors.Is(err, errInvalidDate) {
+		return time.Time{}, usageErrorf("invalid -%s %q: must be a date in the format YYYY-MM-DD, \"today\", \"yesterday\", a number of days ago like \"-3\", a weekday like \"monday\" or \"last-monday\", or a day like \"jan15\", \"Jan 15\" or \"15/1\"", name, value)
+	} else if err != nil {
+		return time.Time{}, usageErrorf("invalid -%s %q: %w", name, value, err)
+	}
+	return t, nil
+}
+
+// errInvalidDate is returned by parseDate for values that aren't dates in any
+// of the accepted formats.
+var errInvalidDate = errors.New("invalid date")
+
+// parseDate parses value as a date (at midnight in the local timezone), either
+// in the format YYYY-MM-DD or as one of the following, relative to the date of
+// now:
+//   - "today" and "yesterday".
+//   - "-N", for N days ago.
+//   - A weekday, e.g. "monday", for the most recent such day (today, if it's a
+//     Monday), or e.g. "last-monday" for the one before that.
+//   - A day of a month, optionally with a year, e.g. "jan15", "Jan 15",
+//     "15 january 2024", "15/1" or "1/15/2024"; see parseFuzzyDate.
+//
+// If value isn't a date, the error is errInvalidDate.
+func parseDate(value string, now time.Time) (time.Time, error) {
+	now = now.Local()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	if n, err := strconv.Atoi(value); err == nil && strings.HasPrefix(value, "-") {
+		return today.AddDate(0, 0, n), nil
+	}
+	name, last := strings.CutPrefix(value, "last-")
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if name != strings.ToLower(wd.String()) {
+			continue
+		}
+		// The number of days since the most recent such weekday.
+		days := (int(today.Weekday()) - int(wd) + 7) % 7
+		if last {
+			days += 7
+		}
+		return today.AddDate(0, 0, -days), nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	return parseFuzzyDate(value, today)
+}
+
+var (
+	// monthDayRE matches a month name followed by a day and optionally a
+	// year, e.g. "jan15" or "january 15, 2024".
+	monthDayRE = regexp.MustCompile(`^([a-z]{3,})\s*(\d{1,2})(?:,?\s*(\d{4}))?$`)
+	// dayMonthRE matches a day followed by a month name and optionally a
+	// year, e.g. "15jan" or "15 january 2024".
+	dayMonthRE = regexp.MustCompile(`^(\d{1,2})\s*([a-z]{3,})(?:,?\s*(\d{4}))?$`)
+	// numericDateRE matches a day and a month in either order, separated by
+	// '/' or '.', and optionally a year, e.g. "15/1" or "1/15/2024".
+	numericDateRE = regexp.MustCompile(`^(\d{1,2})[/.](\d{1,2})(?:[/.](\d{4}))?$`)
+)
+
+// parseFuzzyDate parses value (in lowercase) as a day of a month in one of the
+// formats matched by monthDayRE, dayMonthRE and numericDateRE. Without a year,
+// the date is the most recent such day up to today. Numeric dates are read as
+// day/month or month/day, whichever is valid; if both are, and they're
+// different dates, the date is ambiguous and an error lists both.
+func parseFuzzyDate(value string, today time.Time) (time.Time, error) {
+	var month, day, year string
+	var candidates []time.Time
+	if m := monthDayRE.FindStringSubmatch(value); m != nil {
+		month, day, year = m[1], m[2], m[3]
+	} else if m := dayMonthRE.FindStringSubmatch(value); m != nil {
+		day, month, year = m[1], m[2], m[3]
+	}
+	if month != "" {
+		mon, ok := parseMonth(month)
+		if !ok {
+			return time.Time{}, errInvalidDate
+		}
+		d, _ := strconv.Atoi(day)
+		if t, ok := dateOf(year, int(mon), d, today); ok {
+			candidates = append(candidates, t)
+		}
+	} else if m := numericDateRE.FindStringSubmatch(value); m != nil {
+		a, _ := strconv.Atoi(m[1])
+		b, _ := strconv.Atoi(m[2])
+		if t, ok := dateOf(m[3], b, a, today); ok {
+			candidates = append(candidates, t)
+		}
+		if t, ok := dateOf(m[3], a, b, today); ok && (len(candidates) == 0 || !t.Equal(candidates[0])) {
+			candidates = append(candidates, t)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return time.Time{}, errInvalidDate
+	case 1:
+		return candidates[0], nil
+	default:
+		var dates []string
+		for _, t := range candidates {
+			dates = append(dates, t.Format(time.DateOnly))
+		}
+		return time.Time{}, fmt.Errorf("ambiguous date: could be %s; use YYYY-MM-DD instead", strings.Join(dates, " or "))
+	}
+}
+
+// parseMonth parses the name of a month, or a prefix of at least three
+// letters of it, e.g. "jan" or "sept".
+func parseMonth(name string) (time.Month, bool) {
+	for m := time.January; m <= time.December; m++ {
+		if strings.HasPrefix(strings.ToLower(m.String()), name) {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+// dateOf returns the date with the given month and day in year, or if year is
+// empty, the most recent such date up to today. If there is no such date,
+// e.g. February 30, ok is false.
+func dateOf(year string, month, day int, today time.Time) (t time.Time, ok bool) {
+	valid := func(y int) (time.Time, bool) {
+		t := time.Date(y, time.Month(month), day, 0, 0, 0, 0, time.Local)
+		return t, t.Month() == time.Month(month) && t.Day() == day
+	}
+	if year != "" {
+		y, _ := strconv.Atoi(year)
+		return valid(y)
+	}
+	// Look back a few years, so that February 29 is found too.
+	for y := today.Year(); y > today.Year()-8; y-- {
+		if t, ok := valid(y); ok && !t.After(today) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// dateRange is an inclusive range of dates. A zero since or until means that
+// the range is unbounded in that direction.
+type dateRange struct {
+	since, until time.Time
+}
+
+// parseDateRange parses the values of -since and -until flags into a
+// dateRange. Empty values are unbounded.
+func parseDateRange(since, until string) (dateRange, error) {
+	var r dateRange
+	if since != "" {
+		t, err := parseDateFlag("since", since)
+		if err != nil {
+			return dateRange{}, err
+		}
+		r.since = t
+	}
+	if until != "" {
+		t, err := parseDateFlag("until", until)
+		if err != nil {
+			return dateRange{}, err
+		}
+		r.until = t
+	}
+	if !r.since.IsZero() && !r.until.IsZero() && r.until.Before(r.since) {
+		return dateRange{}, usageErrorf("-until %s is before -since %s", until, since)
+	}
+	return r, nil
+}
+
+// contains reports whether date is within the range.
+func (r dateRange) contains(date time.Time) bool {
+	if !r.since.IsZero() && date.Before(r.since) {
+		return false
+	}
+	if !r.until.IsZero() && date.After(r.until) {
+		return false
+	}
+	return true
+}
+
+// timeOfDay is a flag.Value for a time of day like "03:00", stored as the
+// duration since midnight.
+type timeOfDay time.Duration
+
+func (d *timeOfDay) String() string {
+	m := int(time.Duration(*d) / time.Minute)
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+func (d *timeOfDay) Set(value string) error {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return fmt.Errorf("invalid time of day %q: must be in the format HH:MM", value)
+	}
+	*d = timeOfDay(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+	return nil
+}
+
+// clock returns the current time. It's a variable so that the current time
+// can be pinned, e.g. in tests.
+var clock = time.Now
+
+// dayOf returns the time to use for choosing the snippet file for a snippet
+// recorded at t. With -day_start, the day starts at that time rather than at
+// midnight, so e.g. with -day_start 03:00 a snippet recorded at 02:30 is filed
+// under the previous date.
+func dayOf(t time.Time) time.Time {
+	return t.Local().Add(-time.Duration(dayStart))
+}
+
+// beforeDayStart reports whether the time of day of t is before -day_start,
+// i.e. whether t belongs to the day that started on the previous date.
+func beforeDayStart(t time.Time) bool {
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	return sinceMidnight < time.Duration(dayStart)
+}
+
+// snippetTime returns the time to record a new snippet at: the time given by
+// -at, or otherwise the current time minus -ago.
+func snippetTime() (time.Time, error) {
+	if *at == "" {
+		return clock().Local().Add(-*ago), nil
+	}
+	return parseAt(*at, dayOf(clock()))
+}
+
+// parseAt parses the value of -at, which is a time in the format of the
+// timestamp prefix, optionally preceded by a date (see parseDate) and a space.
+// Without a date, the time is on the date of today.
+func parseAt(value string, today time.Time) (time.Time, error) {
+	layout := timestampLayout()
+	if layout == "" {
+		return time.Time{}, usageErrorf("-at requires a timestamp format in -include_time")
+	}
+	date := today
+	t, err := time.ParseInLocation(layout, value, time.Local)
+	if err != nil {
+		if d, rest, ok := strings.Cut(value, " "); ok {
+			parsed, perr := parseDate(d, today)
+			if perr == nil {
+				date = parsed
+				t, err = time.ParseInLocation(layout, rest, time.Local)
+			}
+		}
+	}
+	if err != nil {
+		return time.Time{}, usageErrorf("invalid -at %q: must be a time in the format %q, optionally preceded by a date, e.g. %q", value, layout, "yesterday "+today.Format(layout))
+	}
+	// If the layout includes a date, e.g. "2006-01-02 15:04", it wins.
+	if t.Year() != 0 {
+		return t, nil
+	}
+	t = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+	// With -day_start, times before the start of the day belong to the next
+	// calendar date, e.g. 01:30 on a day starting at 03:00.
+	if beforeDayStart(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAt(t *testing.T) {
+	today := time.Date(2024, time.November, 20, 14, 0, 0, 0, time.Local)
+	for _, tt := range []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:  "bare time",
+			value: "09:15",
+			want:  time.Date(2024, time.November, 20, 9, 15, 0, 0, time.Local),
+		},
+		{
+			name:  "relative date and time",
+			value: "yesterday 09:15",
+			want:  time.Date(2024, time.November, 19, 9, 15, 0, 0, time.Local),
+		},
+		{
+			name:  "absolute date and time",
+			value: "2024-11-01 17:30",
+			want:  time.Date(2024, time.November, 1, 17, 30, 0, 0, time.Local),
+		},
+		{
+			name:    "invalid time",
+			value:   "9am",
+			wantErr: true,
+		},
+		{
+			name:    "valid date and invalid time",
+			value:   "yesterday 9am",
+			wantErr: true,
+		},
+		{
+			name:    "invalid date",
+			value:   "someday 09:15",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAt(tt.value, today)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseAt(%q) = %v, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAt(%q) failed: %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseAt(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDayOf(t *testing.T) {
+	setFlag(t, "day_start", "03:00")
+	for _, tt := range []struct {
+		t    time.Time
+		want string
+	}{
+		{t: time.Date(2024, time.November, 20, 2, 30, 0, 0, time.Local), want: "2024-11-19"},
+		{t: time.Date(2024, time.November, 20, 2, 59, 0, 0, time.Local), want: "2024-11-19"},
+		{t: time.Date(2024, time.November, 20, 3, 0, 0, 0, time.Local), want: "2024-11-20"},
+		{t: time.Date(2024, time.November, 20, 3, 30, 0, 0, time.Local), want: "2024-11-20"},
+		// Across a month boundary.
+		{t: time.Date(2024, time.December, 1, 2, 30, 0, 0, time.Local), want: "2024-11-30"},
+	} {
+		if got := dayOf(tt.t).Format(time.DateOnly); got != tt.want {
+			t.Errorf("dayOf(%v) = %s, want %s", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	// A Friday.
+	now := time.Date(2024, time.March, 1, 14, 0, 0, 0, time.Local)
+	for _, tt := range []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "today", want: "2024-03-01"},
+		{value: "yesterday", want: "2024-02-29"},
+		{value: " Yesterday ", want: "2024-02-29"},
+		{value: "-1", want: "2024-02-29"},
+		{value: "-3", want: "2024-02-27"},
+		{value: "friday", want: "2024-03-01"},
+		{value: "monday", want: "2024-02-26"},
+		{value: "last-friday", want: "2024-02-23"},
+		{value: "2024-01-15", want: "2024-01-15"},
+		{value: "tomorrow", wantErr: true},
+		{value: "2024-02-30", wantErr: true},
+	} {
+		got, err := parseDate(tt.value, now)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseDate(%q) = %v, want error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseDate(%q) failed: %v", tt.value, err)
+			continue
+		}
+		if got.Format(time.DateOnly) != tt.want || got.Hour() != 0 {
+			t.Errorf("parseDate(%q) = %v, want %s at midnight", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseDateYesterdayAcrossYear(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 0, 30, 0, 0, time.Local)
+	got, err := parseDate("yesterday", now)
+	if err != nil {
+		t.Fatalf("parseDate failed: %v", err)
+	}
+	if want := "2024-12-31"; got.Format(time.DateOnly) != want {
+		t.Errorf("parseDate(%q) on %v = %v, want %s", "yesterday", now, got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/saser/snip/snip"
+)
+
+// runDigest implements the "digest" subcommand, which prints the snippets of a
+// day (today by default) as a summary, e.g. to paste into a chat as an end of
+// day standup update.
+func runDigest(args []string) error {
+	fs := newFlagSet("digest")
+	notebookFlag(fs)
+	date := fs.String("date", "", "Date to summarize snippets for, in the format YYYY-MM-DD. Defaults to today.")
+	stripTime := fs.Bool("strip_time", false, "Leave out the time of each snippet.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	t := dayOf(clock())
+	if d := *date; d != "" {
+		var err error
+		t, err = parseDateFlag("date", d)
+		if err != nil {
+			return fmt.Errorf("digest: %w", err)
+		}
+	}
+	path, err := snippetPath(t)
+	if err != nil {
+		return fmt.Errorf("digest: %w", err)
+	}
+	contents, err := readSnippetFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("digest: %w", err)
+	}
+	lines := snippetLines(contents)
+	if len(lines) == 0 {
+		fmt.Println("no snippets")
+		return nil
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	fmt.Fprintf(w, "Summary for %s:\n", t.Format("Monday Jan 2 2006"))
+	name := snip.FileName(path)
+	for _, line := range lines {
+		s := parseSnippetLine(name, string(line))
+		w.WriteString("- ")
+		if s.prefix != "" && !*stripTime {
+			w.WriteString(s.prefix + " ")
+		}
+		// Indent continuation lines to keep them in the same bullet.
+		w.WriteString(strings.ReplaceAll(s.Text, "\n", "\n  "))
+		w.WriteString("\n")
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("digest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// runDoctor implements the "doctor" subcommand, which checks the environment
+// for common problems, such as an unwritable base directory, a missing editor,
+// or a timezone that can't be inferred, and prints what it found. It returns
+// an error if anything that would stop snip from working is broken.
+func runDoctor(args []string) error {
+	flags := newFlagSet("doctor")
+	notebookFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	problems := 0
+	report := func(status, check, format string, args ...any) {
+		if status == "error" {
+			problems++
+		}
+		fmt.Printf("%-7s %s: %s\n", status, check, fmt.Sprintf(format, args...))
+	}
+
+	// If the base directory isn't a directory, loading the config fails
+	// because of that, which is reported below.
+	if setupErr != nil && checkBaseDir() == nil {
+		report("error", "config", "%v", setupErr)
+	}
+
+	if dir, err := snippetDir(); err != nil {
+		report("error", "snippet directory", "%v", err)
+	} else if err := checkBaseDir(); err != nil {
+		report("error", "snippet directory", "%v", err)
+	} else if err := checkWritable(dir); err != nil {
+		report("error", "snippet directory", "%s is not writable: %v", dir, err)
+	} else if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		report("ok", "snippet directory", "%s (doesn't exist yet, but can be created)", dir)
+	} else {
+		report("ok", "snippet directory", "%s (writable)", dir)
+	}
+
+	editor, source := resolveEditor(), editorSource()
+	if bin, err := exec.LookPath(editor); err != nil {
+		report("error", "editor", "%q (from %s) is not installed or not in $PATH", editor, source)
+	} else {
+		report("ok", "editor", "%s (from %s)", bin, source)
+	}
+
+	if name, err := localTimezone(); err != nil {
+		report("warning", "timezone", "couldn't infer the name of the local timezone, so headers show %q; set $TZ or -timezone: %v", "<unknown timezone>", err)
+	} else {
+		report("ok", "timezone", "%s (from %s)", name, timezoneSource())
+	}
+
+	if runtime.GOOS != "windows" {
+		const localtime = "/etc/localtime"
+		if path, err := filepath.EvalSymlinks(localtime); err != nil {
+			report("warning", localtime, "doesn't resolve: %v", err)
+		} else {
+			report("ok", localtime, "resolves to %s", path)
+		}
+	}
+
+	if problems != 0 {
+		return fmt.Errorf("doctor: found %d problems", problems)
+	}
+	return nil
+}
+
+// editorSource describes where the editor returned by resolveEditor comes
+// from.
+func editorSource() string {
+	switch {
+	case os.Getenv("VISUAL") != "":
+		return "$VISUAL"
+	case os.Getenv("EDITOR") != "":
+		return "$EDITOR"
+	case *editor != "":
+		return "-editor"
+	default:
+		return "the default"
+	}
+}
+
+// timezoneSource describes how the local timezone returned by localTimezone
+// is determined; see [snip.InferLocalTimezone].
+func timezoneSource() string {
+	if *timezone != "" {
+		return "-timezone"
+	}
+	if tz := os.Getenv("TZ"); tz != "" {
+		if _, err := time.LoadLocation(tz); err == nil {
+			return "$TZ"
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "tzutil"
+	}
+	return "the /etc/localtime symlink"
+}
+
+// checkWritable checks that files can be created in dir, or if it doesn't
+// exist, in its closest existing parent, so that dir can be created.
+func checkWritable(dir string) error {
+	for {
+		_, err := os.Stat(dir)
+		if err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if !errors.Is(err, fs.ErrNotExist) || parent == dir {
+			return err
+		}
+		dir = parent
+	}
+	f, err := os.CreateTemp(dir, ".snip-doctor-")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDoctorReportsSetupProblems(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "snippets")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	malformed := t.TempDir()
+	if err := os.WriteFile(filepath.Join(malformed, configFileName), []byte("nonsense\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		dir  string
+		want string
+	}{
+		{
+			name: "base directory is a file",
+			dir:  file,
+			want: "error   snippet directory: " + file + " exists but is not a directory",
+		},
+		{
+			name: "malformed config",
+			dir:  malformed,
+			want: `error   config: load config: ` + filepath.Join(malformed, configFileName) + `: line 1: expected "key = value", got "nonsense"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, code := runSnip(t, "-dir", tt.dir, "doctor")
+			if !strings.Contains(stdout, tt.want) {
+				t.Errorf("snip doctor printed:\n%s\nwant it to contain %q", stdout, tt.want)
+			}
+			if code != exitError || !strings.Contains(stderr, "doctor: found") {
+				t.Errorf("snip doctor: exit code %d, stderr %q; want %d and the number of problems", code, stderr, exitError)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// defaultEditor is the editor to use if no other editor is configured.
+const defaultEditor = "vim"
+
+// resolveEditor returns the editor to use. By longstanding Unix convention,
+// $VISUAL is preferred over $EDITOR. If neither is set, -editor is used, and
+// if that is empty too, defaultEditor.
+func resolveEditor() string {
+	return cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), *editor, defaultEditor)
+}
+
+// expandEditorArgs returns the arguments to pass to the editor to open the file at
+// path with the cursor on the given line, according to -editor_args.
+func expandEditorArgs(path string, line int) []string {
+	var args []string
+	for _, arg := range strings.Fields(*editorArgs) {
+		arg = strings.ReplaceAll(arg, "{file}", path)
+		arg = strings.ReplaceAll(arg, "{line}", strconv.Itoa(line))
+		args = append(args, arg)
+	}
+	return args
+}
+
+// openEditor opens the user's editor on the file at path and waits for it to
+// exit. If the editor supports it (see -editor_args), the cursor is placed on
+// the given line.
+func openEditor(path string, line int) error {
+	editor := resolveEditor()
+	// Look up the editor explicitly, to give a clearer error than the one
+	// from exec.Cmd.Run if it's missing.
+	bin, err := exec.LookPath(editor)
+	if err != nil {
+		return fmt.Errorf("editor %q not found; set $VISUAL, $EDITOR, or -editor to an installed editor: %w", editor, err)
+	}
+	cmd := exec.Command(bin, expandEditorArgs(path, line)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// confirm asks the user a yes/no question on stderr and reads the answer from
+// stdin. An empty answer counts as yes.
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [Y/n] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// lastLine returns the number of the line (starting from 1) at the end of
+// contents, where the user would continue typing.
+func lastLine(contents []byte) int {
+	return bytes.Count(contents, []byte{'\n'}) + 1
+}
+
+// editInTempFile writes initial to a temporary file, opens the user's editor
+// on it, and returns the contents of the file after the editor exits. The
+// temporary file is removed before returning.
+//
+// The file is created in a temporary directory of its own. Some editors (e.g.
+// vim, by default) save by writing a new file and renaming it over the
+// original, possibly leaving backup files next to it. Since the file is read
+// back by path after the editor exits, this picks up the renamed file, and
+// removing the whole directory cleans up anything else the editor left behind.
+func editInTempFile(initial []byte) ([]byte, error) {
+	tmpDir, err := os.MkdirTemp("", "snip-")
+	if err != nil {
+		return nil, fmt.Errorf("create temporary directory for editing snippet: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			slog.Warn("Deleting temporary directory for editing snippet unexpectedly failed", "error", err)
+		}
+	}()
+	path := filepath.Join(tmpDir, "snippet.txt")
+	if err := os.WriteFile(path, initial, fs.FileMode(0o600)); err != nil {
+		return nil, fmt.Errorf("write snippet to temporary file: %w", err)
+	}
+	if err := openEditor(path, lastLine(initial)); err != nil {
+		return nil, fmt.Errorf("open $EDITOR to edit snippet: %w", err)
+	}
+	edited, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read temporary file after editing: %s was removed or renamed by the editor", path)
+	} else if err != nil {
+		return nil, fmt.Errorf("read temporary file after editing: %w", err)
+	}
+	return edited, nil
+}
+
+// backupSuffix is appended to the path of a snippet file to get the path of its
+// backup (see -backup). Backups don't end in ".txt", so they're never mistaken
+// for snippet files.
+const backupSuffix = ".bak"
+
+// writeBackup saves contents, the current contents of the snippet file at path,
+// to its backup file if -backup is set. It's called before rewriting the
+// snippet file, so that the previous version can be recovered if the rewrite
+// goes wrong. Like the snippet file itself, the backup is written atomically.
+func writeBackup(path string, contents []byte) error {
+	if !*backup {
+		return nil
+	}
+	// Back up encrypted snippet files encrypted too.
+	data, err := encodeSnippetFile(path, contents)
+	if err != nil {
+		return fmt.Errorf("back up %s: %w", path, err)
+	}
+	if err := writeFile(path+backupSuffix, data); err != nil {
+		return fmt.Errorf("back up %s: %w", path, err)
+	}
+	return nil
+}
+
+// editLastSnippet opens the last snippet in today's snippet file in the user's
+// editor, and replaces it with the edited version.
+func editLastSnippet() error {
+	return editSnippet(dayOf(clock()), 0)
+}
+
+// editSnippet opens the nth snippet (counting from 1, excluding the header) in
+// the snippet file for t in the user's editor, and replaces it with the edited
+// version. If n is 0, the last snippet is edited.
+func editSnippet(t time.Time, n int) error {
+	op := "edit last snippet"
+	if n != 0 {
+		op = "edit snippet"
+	}
+	path, err := snippetPath(t)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if *refresh {
+		if err := refreshHeader(path, t); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	existing, err := readSnippetFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: read existing snippets: %w", op, err)
+	}
+	header, rest := splitHeader(existing)
+	start, end, ok := snip.LastSnippet(rest)
+	if n != 0 {
+		start, end, ok = snip.NthSnippet(rest, n)
+	}
+	if count := len(snippetLines(existing)); count == 0 {
+		return fmt.Errorf("no snippet to edit")
+	} else if !ok {
+		return usageErrorf("%s: -line %d is out of range: %s has snippets 1 to %d", op, n, path, count)
+	}
+
+	// The timestamp prefix is part of the line, so it's preserved as long as
+	// the user doesn't remove it in the editor.
+	edited, err := editInTempFile([]byte(unindentContinuations(string(rest[start:end]))))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	edited = bytes.TrimSpace(edited)
+	if len(edited) == 0 {
+		return errSnippetLeftEmpty
+	}
+	// Write the text back as it was edited, rather than formatting it anew
+	// according to the current flags, so that the structure of e.g. a -raw
+	// or -bullets snippet is kept.
+	edited = normalizeText(indentContinuations(edited))
+
+	// Don't hold the lock while the user is editing, as that could take a
+	// while. Instead, take it now and check that the file hasn't changed in the
+	// meantime, so that we don't overwrite e.g. a snippet added from another
+	// terminal.
+	unlock, err := lockSnippets()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer unlock()
+	current, err := readSnippetFile(path)
+	if err != nil {
+		return fmt.Errorf("%s: read existing snippets: %w", op, err)
+	}
+	if !bytes.Equal(current, existing) {
+		return fmt.Errorf("%s: %s was changed while editing; please try again", op, path)
+	}
+
+	var assembled bytes.Buffer
+	assembled.Write(header)
+	assembled.Write(rest[:start])
+	assembled.Write(edited)
+	assembled.Write(rest[end:])
+	if *ifChanged && bytes.Equal(assembled.Bytes(), current) {
+		slog.Info("No changes", "file", path)
+		return nil
+	}
+	if err := writeBackup(path, current); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := recordUndo(path, current); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := writeSnippetFile(path, assembled.Bytes()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// deleteLastSnippet removes the last snippet from today's snippet file, and
+// the divider before it if no other snippets are under it. The header, if any,
+// is kept, unless the file would be left completely empty, in
+// which case the file is removed.
+func deleteLastSnippet() error {
+	path, err := snippetPath(dayOf(clock()))
+	if err != nil {
+		return fmt.Errorf("delete last snippet: %w", err)
+	}
+	unlock, err := lockSnippets()
+	if err != nil {
+		return fmt.Errorf("delete last snippet: %w", err)
+	}
+	defer unlock()
+	existing, err := readSnippetFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("delete last snippet: read existing snippets: %w", err)
+	}
+	header, rest := splitHeader(existing)
+	start, _, ok := snip.LastSnippet(rest)
+	if !ok {
+		return fmt.Errorf("no snippets to delete")
+	}
+	if err := writeBackup(path, existing); err != nil {
+		return fmt.Errorf("delete last snippet: %w", err)
+	}
+	if err := recordUndo(path, existing); err != nil {
+		return fmt.Errorf("delete last snippet: %w", err)
+	}
+
+	// If the snippet was the only one under its divider (see -subheaders),
+	// remove the divider too, rather than leaving it behind with nothing
+	// under it.
+	rest = rest[:start]
+	lastLine := bytes.LastIndexByte(bytes.TrimSuffix(rest, []byte{'\n'}), '\n') + 1
+	if snip.IsDivider(bytes.TrimSuffix(rest[lastLine:], []byte{'\n'})) {
+		rest = rest[:lastLine]
+	}
+
+	var assembled bytes.Buffer
+	assembled.Write(header)
+	assembled.Write(rest)
+	if assembled.Len() == 0 {
+		// Rather than leaving a 0-byte file behind, remove it.
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("delete last snippet: %w", err)
+		}
+		return nil
+	}
+	if err := writeSnippetFile(path, assembled.Bytes()); err != nil {
+		return fmt.Errorf("delete last snippet: %w", err)
+	}
+	return nil
+}
+
+// runEdit implements the "edit" subcommand, which opens a snippet in the
+// snippet file for a given day (today by default) in the user's editor, and
+// replaces it with the edited version, like -edit_last does for the last
+// snippet.
+func runEdit(args []string) error {
+	flags := newFlagSet("edit")
+	notebookFlag(flags)
+	date := flags.String("date", "", "Date of the snippet file to edit a snippet in, in the format YYYY-MM-DD. Defaults to today.")
+	line := flags.Int("line", 0, "Number of the snippet to edit, counting from 1 at the top of the snippet file, excluding the header, dividers and blank lines. Defaults to the last snippet.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usageErrorf("edit: unexpected arguments %q", flags.Args())
+	}
+	if *line < 0 {
+		return usageErrorf("edit: invalid -line %d: must be positive", *line)
+	}
+
+	t := dayOf(clock())
+	if d := *date; d != "" {
+		var err error
+		t, err = parseDateFlag("date", d)
+		if err != nil {
+			return fmt.Errorf("edit snippet: %w", err)
+		}
+	}
+	return editSnippet(t, *line)
+}
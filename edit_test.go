@@ -0,0 +1,263 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEditSnippetRoundTrip(t *testing.T) {
+	// An editor that saves the file without changing it.
+	t.Setenv("VISUAL", "true")
+	base := t.TempDir()
+	setFlag(t, "dir", base)
+	const contents = "--- Wednesday Nov 20 2024 in UTC ---\n" +
+		"09:00 | single line\n" +
+		"09:30 | multiline title\n" +
+		"  second line\n" +
+		"  third line\n" +
+		"-- 10:00 --\n" +
+		"10:00 | raw title\n" +
+		"  ```\n" +
+		"  func main() {\n" +
+		"  \n" +
+		"      fmt.Println(\"hi\")\n" +
+		"  }\n" +
+		"  ```\n" +
+		"10:30 | bullets\n" +
+		"  - first\n" +
+		"  - second\n"
+	path := filepath.Join(base, "2024-11-20.txt")
+	date := time.Date(2024, time.November, 20, 0, 0, 0, 0, time.Local)
+	for n := range 4 {
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := editSnippet(date, n+1); err != nil {
+			t.Fatalf("editSnippet(%d) failed: %v", n+1, err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != contents {
+			t.Errorf("editSnippet(%d) without changes rewrote the snippet file to:\n%s\nwant:\n%s", n+1, got, contents)
+		}
+	}
+}
+
+func TestDeleteLastSnippet(t *testing.T) {
+	const header = "--- Wednesday Nov 20 2024 in UTC ---\n"
+	for _, tt := range []struct {
+		name        string
+		contents    string
+		want        string
+		wantRemoved bool
+		wantErr     bool
+	}{
+		{
+			name:     "header only",
+			contents: header,
+			wantErr:  true,
+		},
+		{
+			name:     "single snippet",
+			contents: header + "09:00 | only\n",
+			want:     header,
+		},
+		{
+			name:        "single snippet without a header",
+			contents:    "09:00 | only\n",
+			wantRemoved: true,
+		},
+		{
+			name:     "multiline snippet",
+			contents: header + "09:00 | first\n10:00 | second\n  more\n",
+			want:     header + "09:00 | first\n",
+		},
+		{
+			name:     "only snippet under a divider",
+			contents: header + "-- 09:00 --\n09:00 | first\n-- 10:00 --\n10:00 | second\n",
+			want:     header + "-- 09:00 --\n09:00 | first\n",
+		},
+		{
+			name:     "other snippets under the divider",
+			contents: header + "-- 10:00 --\n10:00 | first\n10:30 | second\n",
+			want:     header + "-- 10:00 --\n10:00 | first\n",
+		},
+		{
+			name:        "only snippet under the only divider",
+			contents:    "-- 10:00 --\n10:00 | only\n",
+			wantRemoved: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, time.November, 20, 11, 0, 0, 0, time.Local)
+			setClock(t, now)
+			base := t.TempDir()
+			setFlag(t, "dir", base)
+			path := filepath.Join(base, "2024-11-20.txt")
+			if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			err := deleteLastSnippet()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("deleteLastSnippet succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("deleteLastSnippet failed: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if tt.wantRemoved {
+				if !os.IsNotExist(err) {
+					t.Errorf("snippet file was not removed: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("snippet file after deleting the last snippet:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveEditor(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		visual     string
+		editor     string
+		editorFlag string
+		want       string
+	}{
+		{name: "VISUAL wins over EDITOR", visual: "code", editor: "nano", editorFlag: "emacs", want: "code"},
+		{name: "EDITOR", editor: "nano", editorFlag: "emacs", want: "nano"},
+		{name: "-editor", editorFlag: "emacs", want: "emacs"},
+		{name: "default", want: "vim"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VISUAL", tt.visual)
+			t.Setenv("EDITOR", tt.editor)
+			setFlag(t, "editor", tt.editorFlag)
+			if got := resolveEditor(); got != tt.want {
+				t.Errorf("resolveEditor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenEditorNotFound(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	setFlag(t, "editor", "snip-no-such-editor")
+	err := openEditor(filepath.Join(t.TempDir(), "snippet.txt"), 1)
+	if err == nil || !strings.Contains(err.Error(), `editor "snip-no-such-editor" not found`) {
+		t.Errorf("openEditor() with a missing editor = %v, want an error naming the editor", err)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	now := time.Date(2024, time.November, 20, 11, 0, 0, 0, time.Local)
+	setClock(t, now)
+	base := t.TempDir()
+	setFlag(t, "dir", base)
+	setFlag(t, "backup", "true")
+	path := filepath.Join(base, "2024-11-20.txt")
+	const contents = "--- Wednesday Nov 20 2024 in UTC ---\n09:00 | first\n10:00 | second\n"
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Appending doesn't rewrite existing snippets, so there's nothing to back
+	// up.
+	if _, err := writeSnippets([][]byte{[]byte("third")}); err != nil {
+		t.Fatalf("writeSnippets failed: %v", err)
+	}
+	if _, err := os.Stat(path + backupSuffix); !os.IsNotExist(err) {
+		t.Errorf("appending a snippet created a backup: %v", err)
+	}
+
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := deleteLastSnippet(); err != nil {
+		t.Fatalf("deleteLastSnippet failed: %v", err)
+	}
+	got, err := os.ReadFile(path + backupSuffix)
+	if err != nil {
+		t.Fatalf("deleting a snippet didn't create a backup: %v", err)
+	}
+	if string(got) != string(before) {
+		t.Errorf("backup:\n%s\nwant the contents before deleting:\n%s", got, before)
+	}
+}
+
+func TestEditInTempFileRenameOnSave(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake editor is a shell script")
+	}
+	// An editor that saves by writing a new file and renaming it over the
+	// original, leaving a backup file behind, like vim does by default.
+	editor := filepath.Join(t.TempDir(), "editor")
+	const script = "#!/bin/sh\n" +
+		"cp \"$1\" \"$1~\"\n" +
+		"printf 'edited\\n' > \"$1.new\"\n" +
+		"mv \"$1.new\" \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", editor)
+	setFlag(t, "editor_args", "{file}")
+	got, err := editInTempFile([]byte("initial\n"))
+	if err != nil {
+		t.Fatalf("editInTempFile failed: %v", err)
+	}
+	if string(got) != "edited\n" {
+		t.Errorf("editInTempFile() = %q, want %q", got, "edited\n")
+	}
+}
+
+func TestEditSnippetIfChanged(t *testing.T) {
+	// An editor that saves the file without changing it.
+	t.Setenv("VISUAL", "true")
+	base := t.TempDir()
+	setFlag(t, "dir", base)
+	path := filepath.Join(base, "2024-11-20.txt")
+	date := time.Date(2024, time.November, 20, 0, 0, 0, 0, time.Local)
+	old := time.Date(2024, time.November, 20, 12, 0, 0, 0, time.Local)
+	for _, tt := range []struct {
+		ifChanged   bool
+		wantWritten bool
+	}{
+		{ifChanged: true, wantWritten: false},
+		{ifChanged: false, wantWritten: true},
+	} {
+		setFlag(t, "if_changed", strconv.FormatBool(tt.ifChanged))
+		if err := os.WriteFile(path, []byte("09:00 | unchanged\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+		if err := editSnippet(date, 1); err != nil {
+			t.Fatalf("editSnippet failed: %v", err)
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if written := !fi.ModTime().Equal(old); written != tt.wantWritten {
+			t.Errorf("with -if_changed=%v, editing without changes rewrote the snippet file: %v, want %v", tt.ifChanged, written, tt.wantWritten)
+		}
+	}
+}
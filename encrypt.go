@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/saser/snip/snip"
+)
+
+// snippetCipher returns the cipher for encrypted snippet files, using the
+// keys given by -age_recipients and -age_identity.
+func snippetCipher() snip.Cipher {
+	return snip.AgeCipher{
+		RecipientsFile: *ageRecipients,
+		IdentityFile:   *ageIdentity,
+	}
+}
+
+// isEncrypted reports whether the snippet file at path is encrypted, based on
+// its name.
+func isEncrypted(path string) bool {
+	return strings.HasSuffix(path, snip.EncryptedExt)
+}
+
+// readSnippetFile reads the snippet file at path, decrypting it if it's
+// encrypted.
+func readSnippetFile(path string) ([]byte, error) {
+	contents, err := readFile(path)
+	if err != nil || !isEncrypted(path) {
+		return contents, err
+	}
+	if !*encrypt {
+		return nil, fmt.Errorf("%s is encrypted; use -encrypt to read it", path)
+	}
+	contents, err = snippetCipher().Decrypt(contents)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return contents, nil
+}
+
+// encodeSnippetFile returns contents as they should be stored in the snippet
+// file at path, i.e. encrypted if the file is encrypted.
+func encodeSnippetFile(path string, contents []byte) ([]byte, error) {
+	if !isEncrypted(path) {
+		return contents, nil
+	}
+	return snippetCipher().Encrypt(contents)
+}
+
+// writeSnippetFile atomically replaces the snippet file at path with contents,
+// encrypting them if the file is encrypted.
+func writeSnippetFile(path string, contents []byte) error {
+	data, err := encodeSnippetFile(path, contents)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// expandEnvVars expands references to environment variables, like $VAR or
+// ${VAR}, in s, for -expand_env. A literal "$" is written as "$$". Undefined
+// variables expand to the empty string, or with -strict_expand, are an error.
+func expandEnvVars(s string) (string, error) {
+	var undefined []string
+	expanded := os.Expand(s, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			undefined = append(undefined, name)
+		}
+		return v
+	})
+	if *strictExpand && len(undefined) != 0 {
+		return "", fmt.Errorf("expand environment variables: undefined: %s", strings.Join(undefined, ", "))
+	}
+	return expanded, nil
+}
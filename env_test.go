@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestExpandEnvVars(t *testing.T) {
+	t.Setenv("SNIP_TEST_TICKET", "ABC-123")
+	t.Setenv("SNIP_TEST_EMPTY", "")
+	for _, tt := range []struct {
+		name    string
+		strict  bool
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{name: "defined", s: "fixed $SNIP_TEST_TICKET", want: "fixed ABC-123"},
+		{name: "braces", s: "fixed ${SNIP_TEST_TICKET}!", want: "fixed ABC-123!"},
+		{name: "defined but empty", strict: true, s: "[$SNIP_TEST_EMPTY]", want: "[]"},
+		{name: "undefined", s: "fixed $SNIP_TEST_UNDEFINED.", want: "fixed ."},
+		{name: "undefined with -strict_expand", strict: true, s: "fixed $SNIP_TEST_UNDEFINED", wantErr: true},
+		{name: "escaped", strict: true, s: "paid $$5 for $SNIP_TEST_TICKET", want: "paid $5 for ABC-123"},
+		{name: "no references", s: "plain text", want: "plain text"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, "strict_expand", strconv.FormatBool(tt.strict))
+			got, err := expandEnvVars(tt.s)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expandEnvVars(%q) = %q, want error", tt.s, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandEnvVars(%q) failed: %v", tt.s, err)
+			}
+			if got != tt.want {
+				t.Errorf("expandEnvVars(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// Exit codes, so that scripts can tell different kinds of failures apart
+// without parsing the error message.
+const (
+	// exitError is for unexpected errors.
+	exitError = 1
+	// exitUsage is for invalid flags or arguments. The flag package also uses
+	// it for flags it can't parse.
+	exitUsage = 2
+	// exitEmpty is for snippets that are empty, including when the user
+	// aborts by leaving the snippet empty in the editor.
+	exitEmpty = 3
+	// exitIO is for failures to read or write files.
+	exitIO = 4
+)
+
+// errUsage matches errors caused by invalid usage, e.g. an invalid flag value,
+// as created by [usageErrorf].
+var errUsage = errors.New("invalid usage")
+
+// usageError is an error caused by invalid usage. It matches errUsage with
+// [errors.Is].
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string        { return e.err.Error() }
+func (e usageError) Unwrap() error        { return e.err }
+func (e usageError) Is(target error) bool { return target == errUsage }
+
+// usageErrorf is like [fmt.Errorf], but marks the error as caused by invalid
+// usage.
+func usageErrorf(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// exitCode returns the exit code to exit with after err.
+func exitCode(err error) int {
+	var (
+		pathErr    *fs.PathError
+		linkErr    *os.LinkError
+		syscallErr *os.SyscallError
+	)
+	switch {
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, errEmptySnippet), errors.Is(err, errSnippetLeftEmpty):
+		return exitEmpty
+	case errors.As(err, &pathErr), errors.As(err, &linkErr), errors.As(err, &syscallErr):
+		return exitIO
+	default:
+		return exitError
+	}
+}
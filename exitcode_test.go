@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "unexpected", err: errors.New("boom"), want: exitError},
+		{name: "usage", err: usageErrorf("invalid -n %d", -1), want: exitUsage},
+		{name: "wrapped usage", err: fmt.Errorf("last: %w", usageErrorf("invalid -n %d", -1)), want: exitUsage},
+		{name: "empty snippet", err: errEmptySnippet, want: exitEmpty},
+		{name: "snippet left empty", err: fmt.Errorf("add: %w", errSnippetLeftEmpty), want: exitEmpty},
+		{name: "path error", err: fmt.Errorf("read: %w", &fs.PathError{Op: "open", Path: "x", Err: fs.ErrPermission}), want: exitIO},
+		{name: "link error", err: &os.LinkError{Op: "rename", Old: "a", New: "b", Err: fs.ErrExist}, want: exitIO},
+		{name: "syscall error", err: os.NewSyscallError("flock", errors.New("boom")), want: exitIO},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// runExport implements the "export" subcommand, which prints the snippets in a
+// date range as a single document.
+func runExport(args []string) error {
+	fs := newFlagSet("export")
+	notebookFlag(fs)
+	since := fs.String("since", "", "First date (YYYY-MM-DD, inclusive) to export snippets for. Defaults to the first date with snippets.")
+	until := fs.String("until", "", "Last date (YYYY-MM-DD, inclusive) to export snippets for. Defaults to the last date with snippets.")
+	format := fs.String("format", "markdown", "Output format: \"markdown\" for a document with a section per day, or \"csv\" for a table with the columns date, time, text and tags (space-separated, without the leading '#'), e.g. for analyzing in a spreadsheet.")
+	frontMatter := fs.Bool("front_matter", false, "Start the document with the front matter of the first exported snippet file that has one (see -header_style), e.g. to publish it with a static site generator.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch *format {
+	case "markdown":
+	case "csv":
+		if *frontMatter {
+			return usageErrorf("export: -front_matter is only supported with -format markdown")
+		}
+	default:
+		return usageErrorf("export: invalid -format %q: must be \"markdown\" or \"csv\"", *format)
+	}
+	r, err := parseDateRange(*since, *until)
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	paths, err := snippetFiles()
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	var cw *csv.Writer
+	if *format == "csv" {
+		cw = csv.NewWriter(w)
+		cw.Write([]string{"date", "time", "text", "tags"})
+	}
+	first := true
+	for _, path := range paths {
+		date, ok := snip.FileDate(path)
+		if !ok || !r.contains(date) {
+			continue
+		}
+		contents, err := readSnippetFile(path)
+		if err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+		lines := snippetLines(contents)
+		if len(lines) == 0 {
+			continue
+		}
+		name := snip.FileName(path)
+		if cw != nil {
+			for _, line := range lines {
+				cw.Write(csvRecord(date, parseSnippetLine(name, string(line))))
+			}
+			continue
+		}
+		// A document can only have one front matter block, so only pass the
+		// first one through.
+		if *frontMatter {
+			if header, _ := splitHeader(contents); snip.IsFrontMatter(header) {
+				w.Write(header)
+				w.WriteString("\n")
+				*frontMatter = false
+			}
+		}
+		if !first {
+			w.WriteString("\n")
+		}
+		first = false
+		fmt.Fprintf(w, "## %s\n\n", date.Format(time.DateOnly))
+		for _, line := range lines {
+			writeMarkdownBullet(w, parseSnippetLine(name, string(line)))
+		}
+	}
+	if cw != nil {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	return nil
+}
+
+// writeMarkdownBullet writes s as a Markdown list item, with the timestamp (if
+// any) in bold. Continuation lines are indented to be part of the list item.
+func writeMarkdownBullet(w *bufio.Writer, s parsedSnippet) {
+	w.WriteString("- ")
+	if s.prefix != "" {
+		fmt.Fprintf(w, "**%s** ", s.prefix)
+	}
+	w.WriteString(strings.ReplaceAll(s.Text, "\n", "\n  "))
+	w.WriteString("\n")
+}
+
+// csvRecord returns s, from the snippet file for date, as a row for -format
+// csv. The time is the time of day of the timestamp, or the raw timestamp
+// prefix if it can't be parsed.
+func csvRecord(date time.Time, s parsedSnippet) []string {
+	tm := s.prefix
+	if t, err := parseTimestamp(s.Date, s.prefix); err == nil {
+		tm = t.Format(time.TimeOnly)
+	}
+	return []string{date.Format(time.DateOnly), tm, s.Text, strings.Join(s.Tags, " ")}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportCSV(t *testing.T) {
+	base := t.TempDir()
+	const contents = "--- Wednesday Nov 20 2024 in UTC ---\n" +
+		"09:00 | met Bob, \"the builder\" #work #people\n" +
+		"10:00 | plain\n"
+	if err := os.WriteFile(filepath.Join(base, "2024-11-20.txt"), []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, code := runSnip(t, "-dir", base, "export", "-format", "csv")
+	if code != 0 {
+		t.Fatalf("snip export failed with exit code %d: %s", code, stderr)
+	}
+	want := "date,time,text,tags\n" +
+		"2024-11-20,09:00:00,\"met Bob, \"\"the builder\"\" #work #people\",work people\n" +
+		"2024-11-20,10:00:00,plain,\n"
+	if stdout != want {
+		t.Errorf("snip export -format csv printed %q, want %q", stdout, want)
+	}
+}
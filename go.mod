@@ -2,4 +2,7 @@ module github.com/saser/snip
 
 go 1.23.2
 
-require github.com/google/renameio/v2 v2.0.0
+require (
+	github.com/google/renameio/v2 v2.0.0
+	golang.org/x/text v0.21.0
+)
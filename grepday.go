@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/saser/snip/snip"
+)
+
+// runGrepDay implements the "grep-day" subcommand, which prints the snippets
+// matching a pattern grouped by snippet file, optionally with the snippets
+// around each match for context.
+func runGrepDay(args []string) error {
+	fs := newFlagSet("grep-day")
+	notebookFlag(fs)
+	ignoreCase := fs.Bool("i", true, "Match case-insensitively.")
+	useRegexp := fs.Bool("regexp", false, "Interpret the pattern as a Go regular expression; see https://pkg.go.dev/regexp/syntax.")
+	since := fs.String("since", "", "First date (YYYY-MM-DD, inclusive) to search snippets for. Defaults to the first date with snippets.")
+	until := fs.String("until", "", "Last date (YYYY-MM-DD, inclusive) to search snippets for. Defaults to the last date with snippets.")
+	context := fs.Int("context", 0, "Number of snippets to print before and after each match, within the same snippet file.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("grep-day: expected exactly one pattern, got %d arguments", fs.NArg())
+	}
+	if *context < 0 {
+		return usageErrorf("grep-day: invalid -context %d: must not be negative", *context)
+	}
+	r, err := parseDateRange(*since, *until)
+	if err != nil {
+		return fmt.Errorf("grep-day: %w", err)
+	}
+	match, err := newMatcher(fs.Arg(0), *ignoreCase, *useRegexp)
+	if err != nil {
+		return fmt.Errorf("grep-day: %w", err)
+	}
+
+	paths, err := snippetFiles()
+	if err != nil {
+		return fmt.Errorf("grep-day: %w", err)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	first := true
+	for _, path := range paths {
+		// Only daily snippet files can be scoped by date; others are
+		// skipped.
+		date, ok := snip.FileDate(path)
+		if !ok || !r.contains(date) {
+			continue
+		}
+		contents, err := readSnippetFile(path)
+		if err != nil {
+			return fmt.Errorf("grep-day: %w", err)
+		}
+		lines := snippetLines(contents)
+		// Mark the snippets to print: the matches and their context.
+		show := make([]bool, len(lines))
+		found := false
+		for i, line := range lines {
+			if !match(string(line)) {
+				continue
+			}
+			found = true
+			for j := max(0, i-*context); j <= min(len(lines)-1, i+*context); j++ {
+				show[j] = true
+			}
+		}
+		if !found {
+			continue
+		}
+
+		if !first {
+			fmt.Fprintln(w)
+		}
+		first = false
+		fmt.Fprintf(w, "== %s ==\n", snip.FileName(path))
+		printed := false
+		for i, line := range lines {
+			if !show[i] {
+				continue
+			}
+			// Separate groups of snippets that aren't adjacent, like grep
+			// does.
+			if printed && !show[i-1] {
+				fmt.Fprintln(w, "--")
+			}
+			printed = true
+			fmt.Fprintf(w, "%s\n", line)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("grep-day: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// formatHeader formats the header line (without a trailing newline) for the
+// snippet file containing snippets timestamped at t, according to
+// -header_format, or the front matter block with -header_style frontmatter.
+func formatHeader(t time.Time) string {
+	frontMatter := *headerStyle == "frontmatter"
+	// Only infer the timezone if it's actually used.
+	var timezone string
+	if frontMatter || strings.Contains(*headerFormat, "%tz") {
+		timezone = displayTimezone(t)
+	}
+	if frontMatter {
+		return snip.FormatFrontMatter(t, timezone)
+	}
+	return snip.FormatHeader(*headerFormat, t, timezone)
+}
+
+// displayTimezone returns the timezone to show in the header for snippets
+// timestamped at t, according to -tz_display: the inferred name of the local
+// timezone, its UTC offset at t (which reflects daylight saving time), or
+// both. The offset doesn't depend on inferring the name, so it's used on its
+// own if that fails.
+func displayTimezone(t time.Time) string {
+	offset := t.Local().Format("-07:00")
+	if *tzDisplay == "offset" {
+		return offset
+	}
+	name, err := localTimezone()
+	if err != nil {
+		slog.Warn("Failed to infer local timezone", "error", err)
+		if *tzDisplay == "both" {
+			return offset
+		}
+		return "<unknown timezone>"
+	}
+	if *tzDisplay == "both" {
+		return fmt.Sprintf("%s (%s)", name, offset)
+	}
+	return name
+}
+
+// splitHeader splits the contents of a snippet file into the header line, as
+// recognized by -header_prefix, and the remaining contents. See
+// [snip.SplitHeader].
+func splitHeader(contents []byte) (header, rest []byte) {
+	return snip.SplitHeader(contents, *headerPrefix)
+}
+
+// refreshHeader replaces the header line of the snippet file at path, if it has
+// one, with a freshly formatted header for snippets timestamped at t. This is
+// useful if e.g. the timezone in the header turned out to be wrong. If the file
+// doesn't exist or doesn't start with a header, it's left alone.
+func refreshHeader(path string, t time.Time) error {
+	unlock, err := lockSnippets()
+	if err != nil {
+		return fmt.Errorf("refresh header: %w", err)
+	}
+	defer unlock()
+	existing, err := readSnippetFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("refresh header: %w", err)
+	}
+	header, rest := splitHeader(existing)
+	fresh := snip.MarkHeader(formatHeader(t)) + "\n"
+	if header == nil || string(header) == fresh {
+		return nil
+	}
+	if err := recordUndo(path, existing); err != nil {
+		return fmt.Errorf("refresh header: %w", err)
+	}
+	if err := writeSnippetFile(path, append([]byte(fresh), rest...)); err != nil {
+		return fmt.Errorf("refresh header: %w", err)
+	}
+	return nil
+}
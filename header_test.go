@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+func TestFormatHeaderInfersTimezoneOnce(t *testing.T) {
+	setFlag(t, "header_format", "--- Monday Jan _2 2006 in %tz ---")
+	inferred := 0
+	previousInfer, previousLocal := inferTimezone, localTimezone
+	inferTimezone = func() (string, error) {
+		inferred++
+		return "Europe/Stockholm", nil
+	}
+	localTimezone = sync.OnceValues(resolveTimezone)
+	t.Cleanup(func() { inferTimezone, localTimezone = previousInfer, previousLocal })
+
+	date := time.Date(2024, time.November, 20, 9, 15, 0, 0, time.Local)
+	for range 3 {
+		if got := formatHeader(date); !strings.HasSuffix(got, "--- Wednesday Nov 20 2024 in Europe/Stockholm ---") {
+			t.Errorf("formatHeader(%v) = %q, want the inferred timezone", date, got)
+		}
+	}
+	if inferred != 1 {
+		t.Errorf("the timezone was inferred %d times, want 1", inferred)
+	}
+}
+
+func TestHeaderAgreesWithFileNameNearMidnight(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("timezone data not available: %v", err)
+	}
+	previous := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = previous })
+
+	// It's already November 20 in UTC, but still November 19 locally.
+	now := time.Date(2024, time.November, 20, 7, 30, 0, 0, time.UTC)
+	setClock(t, now)
+	base := t.TempDir()
+	setFlag(t, "dir", base)
+	setFlag(t, "header_format", "--- Monday Jan _2 2006 ---")
+	if _, err := writeSnippets([][]byte{[]byte("late")}); err != nil {
+		t.Fatalf("writeSnippets failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(base, "2024-11-19.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := snip.HeaderMarker + "--- Tuesday Nov 19 2024 ---\n23:30 | late\n"; string(got) != want {
+		t.Errorf("snippet file 2024-11-19.txt:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRefreshHeader(t *testing.T) {
+	base := t.TempDir()
+	setFlag(t, "dir", base)
+	setFlag(t, "header_format", "--- Monday Jan _2 2006 (%tz) ---")
+	setFlag(t, "timezone", "Europe/Stockholm")
+	previous := localTimezone
+	localTimezone = sync.OnceValues(resolveTimezone)
+	t.Cleanup(func() { localTimezone = previous })
+
+	path := filepath.Join(base, "2024-11-20.txt")
+	// An old-format header, without the marker and with an unknown timezone.
+	const snippets = "09:00 | first\n10:00 | second\n"
+	if err := os.WriteFile(path, []byte("--- Wednesday Nov 20 2024 in <unknown timezone> ---\n"+snippets), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2024, time.November, 20, 0, 0, 0, 0, time.Local)
+	if err := refreshHeader(path, date); err != nil {
+		t.Fatalf("refreshHeader failed: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := snip.HeaderMarker + "--- Wednesday Nov 20 2024 (Europe/Stockholm) ---\n" + snippets; string(got) != want {
+		t.Errorf("snippet file after refreshing the header:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDisplayTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skipf("timezone data not available: %v", err)
+	}
+	previous := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = previous })
+	setFlag(t, "timezone", "Europe/Stockholm")
+	previousLocal := localTimezone
+	localTimezone = sync.OnceValues(resolveTimezone)
+	t.Cleanup(func() { localTimezone = previousLocal })
+
+	winter := time.Date(2024, time.January, 15, 9, 0, 0, 0, loc)
+	summer := time.Date(2024, time.July, 15, 9, 0, 0, 0, loc)
+	for _, tt := range []struct {
+		display string
+		t       time.Time
+		want    string
+	}{
+		{display: "name", t: winter, want: "Europe/Stockholm"},
+		{display: "offset", t: winter, want: "+01:00"},
+		{display: "offset", t: summer, want: "+02:00"},
+		{display: "both", t: winter, want: "Europe/Stockholm (+01:00)"},
+		{display: "both", t: summer, want: "Europe/Stockholm (+02:00)"},
+	} {
+		setFlag(t, "tz_display", tt.display)
+		if got := displayTimezone(tt.t); got != tt.want {
+			t.Errorf("displayTimezone(%v) with -tz_display %s = %q, want %q", tt.t, tt.display, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// helpTopic describes a subcommand for the help output.
+type helpTopic struct {
+	// summary is a one-line description of the subcommand.
+	summary string
+	// examples are example command lines, optionally followed by a comment
+	// starting with "#".
+	examples []string
+}
+
+// helpTopics describe the subcommands, by name. Subcommands without a topic
+// are still listed in the help output, just without a description.
+var helpTopics = map[string]helpTopic{
+	"add": {
+		summary: "Add a snippet, or edit or delete the last one (the default)",
+		examples: []string{
+			"snip -m 'reviewed the MR'",
+			"snip -edit -m 'design review'      # add details in the editor",
+			"snip -ago 15m -m 'standup'         # backdate the snippet",
+			"snip -edit_last                    # fix a typo in the last snippet",
+		},
+	},
+	"completion": {
+		summary:  "Print a shell completion script",
+		examples: []string{"source <(snip completion bash)"},
+	},
+	"count": {
+		summary:  "Print the number of snippets per day",
+		examples: []string{"snip count -since last-monday"},
+	},
+	"digest": {
+		summary:  "Print a day's snippets as a summary for standup",
+		examples: []string{"snip digest -strip_time"},
+	},
+	"doctor": {
+		summary:  "Check the environment for common problems",
+		examples: []string{"snip doctor"},
+	},
+	"edit": {
+		summary:  "Edit a snippet of a day in the editor",
+		examples: []string{"snip edit -date 2024-01-15 -line 3"},
+	},
+	"export": {
+		summary:  "Export snippets in a date range as Markdown or CSV",
+		examples: []string{"snip export -since 2024-11-18 -until 2024-11-20 > review.md"},
+	},
+	"grep-day": {
+		summary:  "Search snippets, grouped by day, with context",
+		examples: []string{"snip grep-day -context 1 'prometheus'"},
+	},
+	"help": {
+		summary:  "Print help about snip or a subcommand",
+		examples: []string{"snip help list"},
+	},
+	"import": {
+		summary:  "Import snippets from a file",
+		examples: []string{"snip import -file notes.txt"},
+	},
+	"last": {
+		summary:  "Print the most recent snippets, regardless of day",
+		examples: []string{"snip last -n 3"},
+	},
+	"list": {
+		summary: "Print the snippets of a day",
+		examples: []string{
+			"snip list -date yesterday",
+			"snip list -format json",
+		},
+	},
+	"migrate": {
+		summary:  "Change the separator after the timestamps in all snippet files",
+		examples: []string{"snip migrate -from ' | ' -to ' — ' -backup"},
+	},
+	"open": {
+		summary:  "Open a snippet file in the editor",
+		examples: []string{"snip open -date 2024-11-18"},
+	},
+	"path": {
+		summary:  "Print the path of a snippet file",
+		examples: []string{"snip path -date yesterday"},
+	},
+	"recent": {
+		summary:  "Print the last days with snippets",
+		examples: []string{"snip recent -n 7"},
+	},
+	"search": {
+		summary: "Search all snippets",
+		examples: []string{
+			"snip search 'prometheus'",
+			"snip search -regexp 'deploy(ed)? to prod'",
+		},
+	},
+	"stats": {
+		summary:  "Print statistics about the snippets",
+		examples: []string{"snip stats"},
+	},
+	"tags": {
+		summary:  "Print all tags with how often they're used",
+		examples: []string{"snip tags"},
+	},
+	"undo": {
+		summary:  "Undo the last change to a snippet file",
+		examples: []string{"snip undo"},
+	},
+	"verify": {
+		summary:  "Check snippet files for problems",
+		examples: []string{"snip verify"},
+	},
+	"watch": {
+		summary:  "Print today's snippets as they're added",
+		examples: []string{"snip watch -interval 5s"},
+	},
+	"wordcount": {
+		summary:  "Print the number of words written per day",
+		examples: []string{"snip wordcount -since -7"},
+	},
+}
+
+// usage prints the usage of snip, including the subcommands, a few examples,
+// and the global flags.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: snip [flags] [subcommand] [subcommand flags]\n\n")
+	fmt.Fprintf(out, "Subcommands (\"add\" is the default):\n")
+	names := subcommandNames()
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-*s  %s\n", width, name, helpTopics[name].summary)
+	}
+	fmt.Fprintf(out, "\nExamples:\n")
+	for _, name := range []string{"add", "list", "search"} {
+		for _, example := range helpTopics[name].examples {
+			fmt.Fprintf(out, "  %s\n", example)
+		}
+	}
+	fmt.Fprintf(out, "\nRun \"snip help <subcommand>\" for the flags of a subcommand.\n")
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// runHelp implements the "help" subcommand, which prints the usage of snip, or
+// with an argument, the description, examples and flags of that subcommand.
+func runHelp(args []string) error {
+	fs := newFlagSet("help")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// The help was asked for, so print it to stdout, e.g. to be paged,
+	// including the flags printed by the subcommand's flag set.
+	flag.CommandLine.SetOutput(os.Stdout)
+	flagOutput = os.Stdout
+	switch fs.NArg() {
+	case 0:
+		usage()
+		return nil
+	case 1:
+	default:
+		return usageErrorf("help: expected at most one subcommand, got %d arguments", fs.NArg())
+	}
+
+	name := fs.Arg(0)
+	sub, ok := subcommands[name]
+	if !ok {
+		return usageErrorf("help: unknown subcommand %q; must be one of %s", name, strings.Join(subcommandNames(), ", "))
+	}
+	topic := helpTopics[name]
+	if topic.summary != "" {
+		fmt.Printf("snip %s: %s\n", name, topic.summary)
+	}
+	if len(topic.examples) != 0 {
+		fmt.Printf("\nExamples:\n")
+		for _, example := range topic.examples {
+			fmt.Printf("  %s\n", example)
+		}
+	}
+	fmt.Println()
+	// Let the subcommand print its own flags, so that they're always
+	// accurate. Its flag set exits after printing them.
+	return sub([]string{"-h"})
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunHelpKeepsStderr(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout = f
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		f.Close()
+		flagOutput = nil
+		flag.CommandLine.SetOutput(nil)
+	})
+	if err := runHelp(nil); err != nil {
+		t.Fatalf("runHelp failed: %v", err)
+	}
+	if os.Stderr != stderr {
+		t.Error("runHelp replaced os.Stderr")
+	}
+	out, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(out), "Usage: snip") {
+		t.Errorf("runHelp printed %q to stdout, want the usage", out)
+	}
+}
+
+func TestHelpSubcommand(t *testing.T) {
+	stdout, stderr, code := runSnip(t, "help", "last")
+	if code != 0 {
+		t.Fatalf("snip help last failed with exit code %d: %s", code, stderr)
+	}
+	if !strings.HasPrefix(stdout, "snip last: ") || !strings.Contains(stdout, "-n int") {
+		t.Errorf("snip help last printed %q to stdout, want the summary and the flags", stdout)
+	}
+	if stderr != "" {
+		t.Errorf("snip help last printed %q to stderr, want nothing", stderr)
+	}
+}
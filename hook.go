@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// runHook runs the -hook command to transform a snippet line before it's
+// written. The line (including the timestamp prefix, if any) is written to the
+// command's stdin, and its stdout is used as the line instead. If the command
+// fails, e.g. because it rejected the snippet, the snippet isn't written.
+func runHook(line []byte) ([]byte, error) {
+	cmd := exec.Command(*hook)
+	cmd.Stdin = bytes.NewReader(append(bytes.Clone(line), '\n'))
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("run hook %s: %w", *hook, err)
+	}
+	out = bytes.TrimSpace(out)
+	if len(out) == 0 {
+		return nil, fmt.Errorf("run hook %s: output is empty", *hook)
+	}
+	// The hook may have added or removed line breaks, so format the output
+	// like any other snippet text.
+	return formatSnippetText([]byte(unindentContinuations(string(out)))), nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// writeScript writes a shell script with the given body to a temporary file
+// and returns its path.
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "script")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHook(t *testing.T) {
+	now := time.Date(2024, time.November, 20, 9, 15, 0, 0, time.Local)
+	setClock(t, now)
+	setFlag(t, "dir", t.TempDir())
+	setFlag(t, "hook", writeScript(t, "tr a-z A-Z\n"))
+	if _, err := writeSnippets([][]byte{[]byte("shout")}); err != nil {
+		t.Fatalf("writeSnippets failed: %v", err)
+	}
+	if got, want := readSnippets(t, now), "09:15 | SHOUT\n"; got != want {
+		t.Errorf("snippets:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHookFails(t *testing.T) {
+	now := time.Date(2024, time.November, 20, 9, 15, 0, 0, time.Local)
+	setClock(t, now)
+	base := t.TempDir()
+	setFlag(t, "dir", base)
+	setFlag(t, "hook", writeScript(t, "exit 1\n"))
+	if _, err := writeSnippets([][]byte{[]byte("rejected")}); err == nil {
+		t.Fatal("writeSnippets with a failing hook succeeded, want error")
+	}
+	if _, err := os.Stat(filepath.Join(base, "2024-11-20.txt")); !os.IsNotExist(err) {
+		t.Errorf("the snippet file was written despite the failing hook: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// runImport implements the "import" subcommand, which adds the snippets from a
+// plain-text log with one timestamped entry per line to the snippet files.
+func runImport(args []string) error {
+	fs := newFlagSet("import")
+	notebookFlag(fs)
+	file := fs.String("file", "", "Path of the file to import.")
+	layout := fs.String("layout", "2006-01-02 15:04", "Layout of the timestamp at the start of each line. Please refer to https://pkg.go.dev/time to read about time formats.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return usageErrorf("import: -file is required")
+	}
+	if strings.TrimSpace(*layout) == "" {
+		return usageErrorf("import: -layout must not be empty")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	defer f.Close()
+	if err := checkBaseDir(); err != nil {
+		return err
+	}
+	a, err := newAppender()
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	// Group the lines by the snippet file they go in, so that each file is
+	// written once, and the import can be undone as one change per file.
+	type fileLines struct {
+		t     time.Time
+		lines [][]byte
+	}
+	var (
+		paths   []string
+		byPath  = make(map[string]*fileLines)
+		skipped int
+	)
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		t, text, err := parseImportLine(line, *layout)
+		if err != nil {
+			slog.Warn("Skipped line", "file", *file, "line", n, "error", err)
+			skipped++
+			continue
+		}
+		snippet := text
+		if *includeTime != "" && !*noTimestamp {
+			snippet = timestampPrefix(t) + snippet
+		}
+		path, err := snippetPath(t)
+		if err != nil {
+			return fmt.Errorf("import: %s:%d: %w", *file, n, err)
+		}
+		fl, ok := byPath[path]
+		if !ok {
+			fl = &fileLines{t: t}
+			byPath[path] = fl
+			paths = append(paths, path)
+		}
+		fl.lines = append(fl.lines, []byte(snippet))
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	imported := 0
+	for _, path := range paths {
+		fl := byPath[path]
+		res, err := a.Append(context.Background(), snip.AppendOptions{
+			Lines: fl.lines,
+			Time:  fl.t,
+		})
+		if err != nil {
+			return fmt.Errorf("import: %s: %w", path, err)
+		}
+		imported += res.Appended
+	}
+	fmt.Printf("Imported %d snippets, skipped %d lines.\n", imported, skipped)
+	return nil
+}
+
+// parseImportLine parses a line to import into its leading timestamp, which
+// has the given layout, and the snippet text after it. The timestamp is assumed
+// to span as many space-separated fields as the layout. A separator after the
+// timestamp (see -separator) is dropped.
+func parseImportLine(line, layout string) (t time.Time, text string, err error) {
+	// Cut off one field at a time, so that the whitespace in the text is
+	// preserved.
+	var fields []string
+	rest := line
+	for range len(strings.Fields(layout)) {
+		rest = strings.TrimLeft(rest, " \t")
+		i := strings.IndexAny(rest, " \t")
+		if i == -1 {
+			i = len(rest)
+		}
+		fields = append(fields, rest[:i])
+		rest = rest[i:]
+	}
+	t, err = time.ParseInLocation(layout, strings.Join(fields, " "), time.Local)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	text = strings.TrimSpace(rest)
+	for _, sep := range timestampSeparators() {
+		if sep := strings.TrimSpace(sep); sep != "" {
+			text = strings.TrimSpace(strings.TrimPrefix(text, sep))
+		}
+	}
+	if text == "" {
+		return time.Time{}, "", fmt.Errorf("no snippet text after the timestamp")
+	}
+	return t, text, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestImport(t *testing.T) {
+	setClock(t, time.Date(2024, time.November, 22, 9, 0, 0, 0, time.Local))
+	base := t.TempDir()
+	setFlag(t, "dir", base)
+	file := filepath.Join(t.TempDir(), "log.txt")
+	const log = "2024-11-20 09:00 first\n" +
+		"2024-11-21 08:00 other day\n" +
+		"not a timestamp\n" +
+		"2024-11-20 10:00 | second\n" +
+		"2024-11-20 11:00 third\n"
+	if err := os.WriteFile(file, []byte(log), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runImport([]string{"-file", file}); err != nil {
+		t.Fatalf("runImport failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		date time.Time
+		want string
+	}{
+		{
+			date: time.Date(2024, time.November, 20, 0, 0, 0, 0, time.Local),
+			want: "09:00 | first\n10:00 | second\n11:00 | third\n",
+		},
+		{
+			date: time.Date(2024, time.November, 21, 0, 0, 0, 0, time.Local),
+			want: "08:00 | other day\n",
+		},
+	} {
+		if got := readSnippets(t, tt.date); got != tt.want {
+			t.Errorf("snippets for %s = %q, want %q", tt.date.Format(time.DateOnly), got, tt.want)
+		}
+		// Each snippet file is written once, so that the import can be
+		// undone in one go.
+		path, err := snippetPath(tt.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logPath, err := undoLogPath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := readUndoLog(logPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("undo log for %s has %d entries, want 1", path, len(entries))
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/saser/snip/snip"
+)
+
+// runLast implements the "last" subcommand, which prints the most recent
+// snippets, regardless of which day they were recorded on.
+func runLast(args []string) error {
+	fs := newFlagSet("last")
+	notebookFlag(fs)
+	n := fs.Int("n", 1, "Number of snippets to print.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *n < 1 {
+		return usageErrorf("last: invalid -n %d: must be at least 1", *n)
+	}
+
+	last, err := lastSnippets(*n)
+	if err != nil {
+		return fmt.Errorf("last: %w", err)
+	}
+	if len(last) == 0 {
+		return fmt.Errorf("last: no snippets found")
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	for _, s := range last {
+		fmt.Fprintln(w, s)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("last: %w", err)
+	}
+	return nil
+}
+
+// lastSnippets returns the n most recent snippet lines across all snippet
+// files, or fewer if there aren't that many, in chronological order. Each line
+// is prefixed with the name of its snippet file, e.g. "2024-01-15".
+func lastSnippets(n int) ([]string, error) {
+	paths, err := snippetFiles()
+	if err != nil {
+		return nil, err
+	}
+	// Walk backwards from the newest snippet file until enough snippets have
+	// been found, skipping files without any snippets (e.g. only a header).
+	var last []string
+	for i := len(paths) - 1; i >= 0 && len(last) < n; i-- {
+		path := paths[i]
+		if _, ok := snip.FileDate(path); !ok {
+			continue
+		}
+		contents, err := readSnippetFile(path)
+		if err != nil {
+			return nil, err
+		}
+		date := snip.FileName(path)
+		lines := snippetLines(contents)
+		for j := len(lines) - 1; j >= 0 && len(last) < n; j-- {
+			last = append(last, fmt.Sprintf("%s %s", date, lines[j]))
+		}
+	}
+	// Return the snippets in chronological order, like the other subcommands
+	// print them.
+	slices.Reverse(last)
+	return last, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLastSnippets(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		files map[string]string
+		n     int
+		want  []string
+	}{
+		{
+			name: "newest file has only a header",
+			files: map[string]string{
+				"2024-11-19.txt": "--- Tuesday Nov 19 2024 in UTC ---\n09:00 | first\n10:00 | second\n",
+				"2024-11-20.txt": "--- Wednesday Nov 20 2024 in UTC ---\n",
+			},
+			n:    1,
+			want: []string{"2024-11-19 10:00 | second"},
+		},
+		{
+			name: "across files",
+			files: map[string]string{
+				"2024-11-19.txt": "--- Tuesday Nov 19 2024 in UTC ---\n09:00 | first\n10:00 | second\n",
+				"2024-11-20.txt": "--- Wednesday Nov 20 2024 in UTC ---\n08:00 | third\n",
+			},
+			n:    2,
+			want: []string{"2024-11-19 10:00 | second", "2024-11-20 08:00 | third"},
+		},
+		{
+			name: "week and month files",
+			files: map[string]string{
+				"2024-10.txt":    "--- Tuesday Oct 1 2024 in UTC ---\n09:00 | month\n",
+				"2024-W47.txt":   "--- Monday Nov 18 2024 in UTC ---\n09:00 | week\n",
+				"2024-W48.txt":   "--- Monday Nov 25 2024 in UTC ---\n",
+				"not-a-date.txt": "09:00 | ignored\n",
+			},
+			n:    3,
+			want: []string{"2024-10 09:00 | month", "2024-W47 09:00 | week"},
+		},
+		{
+			name: "no snippets",
+			files: map[string]string{
+				"2024-11-20.txt": "--- Wednesday Nov 20 2024 in UTC ---\n",
+			},
+			n: 1,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			base := t.TempDir()
+			setFlag(t, "dir", base)
+			for name, contents := range tt.files {
+				if err := os.WriteFile(filepath.Join(base, name), []byte(contents), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := lastSnippets(tt.n)
+			if err != nil {
+				t.Fatalf("lastSnippets(%d) failed: %v", tt.n, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("lastSnippets(%d) = %q, want %q", tt.n, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// fetchTitleTimeout is how long -fetch_title waits for the page, so that a
+	// slow server doesn't hold up adding the snippet.
+	fetchTitleTimeout = 5 * time.Second
+	// maxPageSize is how much of the page -fetch_title reads looking for the
+	// title, which is usually near the top.
+	maxPageSize = 1 << 20
+)
+
+// titleRE matches the <title> element of an HTML page.
+var titleRE = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// validateLink checks the value of -link, which must be an absolute URL.
+func validateLink(link string) error {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return usageErrorf("invalid -link %q: must be an absolute URL, e.g. \"https://example.com\"", link)
+	}
+	return nil
+}
+
+// linkText returns the snippet text for -link: a Markdown link to link with
+// the given title, or if the title is empty, the title of the page with
+// -fetch_title, and otherwise the URL itself.
+func linkText(title, link string) string {
+	if title == "" && *fetchTitle {
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTitleTimeout)
+		defer cancel()
+		var err error
+		title, err = fetchPageTitle(ctx, link)
+		if err != nil {
+			slog.Warn("Fetching the page title failed; using the URL instead", "url", link, "error", err)
+		}
+	}
+	if title == "" {
+		return link
+	}
+	// Brackets would end the link text early.
+	title = strings.NewReplacer(`[`, `\[`, `]`, `\]`).Replace(title)
+	return fmt.Sprintf("[%s](%s)", title, link)
+}
+
+// fetchPageTitle fetches the HTML page at link and returns its title, with
+// whitespace collapsed.
+func fetchPageTitle(ctx context.Context, link string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", fmt.Errorf("fetch title: %w", err)
+	}
+	req.Header.Set("User-Agent", "snip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch title: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch title: %s", resp.Status)
+	}
+	page, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return "", fmt.Errorf("fetch title: %w", err)
+	}
+	m := titleRE.FindSubmatch(page)
+	if m == nil {
+		return "", errors.New("fetch title: the page has no title")
+	}
+	title := strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " ")
+	if title == "" {
+		return "", errors.New("fetch title: the page has an empty title")
+	}
+	return title, nil
+}
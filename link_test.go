@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestValidateLink(t *testing.T) {
+	for _, link := range []string{"https://example.com", "http://example.com/a?b=c"} {
+		if err := validateLink(link); err != nil {
+			t.Errorf("validateLink(%q) = %v, want nil", link, err)
+		}
+	}
+	for _, link := range []string{"example.com", "/path", "https://", "://example.com"} {
+		if err := validateLink(link); exitCode(err) != exitUsage {
+			t.Errorf("validateLink(%q) = %v, want a usage error", link, err)
+		}
+	}
+}
+
+func TestLinkText(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		switch r.URL.Path {
+		case "/article":
+			w.Write([]byte("<html><head><title>\n  Tips &amp; [tricks]\n</title></head></html>"))
+		case "/untitled":
+			w.Write([]byte("<html><body>no title</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		name       string
+		title      string
+		link       string
+		fetchTitle bool
+		want       string
+	}{
+		{
+			name:  "title",
+			title: "Read this",
+			link:  srv.URL + "/article",
+			want:  "[Read this](" + srv.URL + "/article)",
+		},
+		{
+			name: "no title and no fetching",
+			link: srv.URL + "/article",
+			want: srv.URL + "/article",
+		},
+		{
+			name:       "title is not fetched if given",
+			title:      "Read this",
+			link:       srv.URL + "/article",
+			fetchTitle: true,
+			want:       "[Read this](" + srv.URL + "/article)",
+		},
+		{
+			name:       "fetched title",
+			link:       srv.URL + "/article",
+			fetchTitle: true,
+			want:       `[Tips & \[tricks\]](` + srv.URL + "/article)",
+		},
+		{
+			name:       "page without a title",
+			link:       srv.URL + "/untitled",
+			fetchTitle: true,
+			want:       srv.URL + "/untitled",
+		},
+		{
+			name:       "missing page",
+			link:       srv.URL + "/missing",
+			fetchTitle: true,
+			want:       srv.URL + "/missing",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, "fetch_title", strconv.FormatBool(tt.fetchTitle))
+			fetches.Store(0)
+			if got := linkText(tt.title, tt.link); got != tt.want {
+				t.Errorf("linkText(%q, %q) = %q, want %q", tt.title, tt.link, got, tt.want)
+			}
+			if wantFetch := tt.fetchTitle && tt.title == ""; (fetches.Load() > 0) != wantFetch {
+				t.Errorf("linkText(%q, %q) fetched the page %d times, want fetched: %v", tt.title, tt.link, fetches.Load(), wantFetch)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// runList implements the "list" subcommand, which prints the contents of the
+// snippet file for a given day (today by default) to stdout.
+func runList(args []string) error {
+	fs := newFlagSet("list")
+	notebookFlag(fs)
+	colorFlag(fs)
+	date := fs.String("date", "", "Date to list snippets for, in the format YYYY-MM-DD. Defaults to today.")
+	noHeader := fs.Bool("no_header", false, "Don't print the header line of the snippet file.")
+	format := fs.String("format", "text", "Output format: \"text\" prints the snippet file as-is; \"json\" prints each snippet as a JSON object with the fields \"date\", \"time\", \"text\", \"tags\", \"author\", and \"priority\", one per line.")
+	byAuthor := fs.String("author", "", "Only list the snippets attributed to this author (see -attribute).")
+	byPriority := fs.String("priority", "", "Only list the snippets marked with this priority (see -priority): \"high\", \"med\", or \"low\".")
+	print0 := fs.Bool("print0", false, "Print each snippet terminated by a NUL byte, without the header, dividers or colors, e.g. for xargs -0. Only supported with -format text.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validatePriority("priority", *byPriority); err != nil {
+		return fmt.Errorf("list snippets: %w", err)
+	}
+	if *print0 && *format != "text" {
+		return usageErrorf("list snippets: -print0 is only supported with -format text")
+	}
+	switch *format {
+	case "text", "json":
+	default:
+		return usageErrorf("list snippets: invalid -format %q: must be \"text\" or \"json\"", *format)
+	}
+
+	t := dayOf(clock())
+	if d := *date; d != "" {
+		var err error
+		t, err = parseDateFlag("date", d)
+		if err != nil {
+			return fmt.Errorf("list snippets: %w", err)
+		}
+	}
+	path, err := snippetPath(t)
+	if err != nil {
+		return fmt.Errorf("list snippets: %w", err)
+	}
+	contents, err := readSnippetFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no snippets for %s", t.Format(time.DateOnly))
+	} else if err != nil {
+		return fmt.Errorf("list snippets: %w", err)
+	}
+	authorName := strings.TrimPrefix(*byAuthor, "@")
+	name := snip.FileName(path)
+	filter := authorName != "" || *byPriority != ""
+	matches := func(s parsedSnippet) bool {
+		return (authorName == "" || s.Author == authorName) && (*byPriority == "" || s.Priority == *byPriority)
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		for _, line := range snippetLines(contents) {
+			s := parseSnippetLine(name, string(line))
+			if !matches(s) {
+				continue
+			}
+			if err := enc.Encode(s); err != nil {
+				return fmt.Errorf("list snippets: %w", err)
+			}
+		}
+		return nil
+	}
+	header, rest := splitHeader(contents)
+	// When filtering or with -print0, only the snippets are printed, without
+	// any dividers or blank lines between them.
+	if filter || *print0 {
+		term := byte('\n')
+		if *print0 {
+			header, term = nil, 0
+		}
+		var b bytes.Buffer
+		for _, line := range snippetLines(contents) {
+			if !filter || matches(parseSnippetLine(name, string(line))) {
+				b.Write(line)
+				b.WriteByte(term)
+			}
+		}
+		rest = b.Bytes()
+	}
+	if *noHeader {
+		header = nil
+	}
+	contents = slices.Concat(header, rest)
+	if useColor() && !*print0 {
+		contents = colorSnippetFile(header, rest)
+	}
+	if _, err := os.Stdout.Write(contents); err != nil {
+		return fmt.Errorf("list snippets: %w", err)
+	}
+	return nil
+}
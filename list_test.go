@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListPrint0(t *testing.T) {
+	base := t.TempDir()
+	const contents = "--- Wednesday Nov 20 2024 in UTC ---\n-- 09:00 --\n09:00 | a b c\n  more\n10:00 | d\n"
+	if err := os.WriteFile(filepath.Join(base, "2024-11-20.txt"), []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, code := runSnip(t, "-dir", base, "list", "-date", "2024-11-20", "-print0", "-color", "always")
+	if code != 0 {
+		t.Fatalf("snip list failed with exit code %d: %s", code, stderr)
+	}
+	if want := "09:00 | a b c\n  more\x0010:00 | d\x00"; stdout != want {
+		t.Errorf("snip list -print0 printed %q, want %q", stdout, want)
+	}
+}
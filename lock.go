@@ -0,0 +1,18 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/saser/snip/snip"
+)
+
+// lockSnippets takes an exclusive advisory lock on the snippet files in the
+// base directory (see [snip.Lock]). The returned function releases the lock.
+func lockSnippets() (unlock func(), err error) {
+	base, err := baseDir()
+	if err != nil {
+		return nil, fmt.Errorf("lock snippets: %w", err)
+	}
+	return snip.Lock(context.Background(), base)
+}
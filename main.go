@@ -3,240 +3,861 @@ package main
 import (
 	"bytes"
 	"cmp"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
+	"log/slog"
+	"maps"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
-	"github.com/google/renameio/v2"
+	"github.com/saser/snip/snip"
 )
 
 var (
-	message       = flag.String("m", "", "Title of the snippet. If this is empty then $EDITOR will open to write the snippet, ignoring the -edit flag.")
-	edit          = flag.Bool("edit", false, "Open $EDITOR to edit the snippet. Only has effect if -m is specified. If $EDITOR is empty then vim will be used; if vim is not present on the system, an error is returned.")
-	includeTime   = flag.String("include_time", "15:04 | ", "Format of pre-filled timestamp in snippet. Please refer to https://pkg.go.dev/time to read about time formats. Leave this empty to not include a timestamp.")
+	message       = flag.String("m", "", "Title of the snippet. If this is empty then $EDITOR will open to write the snippet, ignoring the -edit flag. If stdin is not a terminal, the snippet is read from stdin and appended to the title instead. A value like \"@notes.txt\" reads the snippet from that file instead; use \"@@\" for a literal leading '@'.")
+	edit          = flag.Bool("edit", false, "Open $EDITOR to edit the snippet. Only has effect if -m is specified. If $VISUAL and $EDITOR are empty then the editor from -editor will be used; if it is not present on the system, an error is returned.")
+	includeTime   = flag.String("include_time", "15:04 | ", "Format of pre-filled timestamp in snippet. Please refer to https://pkg.go.dev/time to read about time formats. Leave this empty to not include a timestamp. The timestamp must not contain path separators.")
+	separator     = flag.String("separator", snip.TimestampSeparator, "Separator between the timestamp and the text of a snippet, e.g. \" - \" or a tab. When reading snippet files, both this and the default separator \" | \" are recognized.")
+	noTimestamp   = flag.Bool("no_timestamp", false, "Don't prepend a timestamp to the snippet, regardless of -include_time.")
 	includeHeader = flag.Bool("include_header", true, "Include a header containing the current date and timezone as the first line in the snippet file.")
+	headerFormat  = flag.String("header_format", snip.DefaultHeaderFormat, "Format of the header line. Please refer to https://pkg.go.dev/time to read about time formats. The placeholder %tz is replaced with the name of the local timezone. The header must start with -header_prefix.")
+	headerStyle   = flag.String("header_style", "line", "Style of the header: \"line\" for a single line formatted according to -header_format, or \"frontmatter\" for a YAML front matter block with the date and timezone, e.g. for static site generators.")
+	tzDisplay     = flag.String("tz_display", "name", "How to show the timezone in the header: \"name\" for the IANA name, e.g. \"Europe/Stockholm\", \"offset\" for the UTC offset, e.g. \"+01:00\", or \"both\", e.g. \"Europe/Stockholm (+01:00)\".")
+	headerPrefix  = flag.String("header_prefix", snip.DefaultHeaderPrefix, "Prefix used to recognize the header line in existing snippet files. Headers produced by -header_format must start with it.")
+	editor        = flag.String("editor", defaultEditor, "Editor to use if $VISUAL and $EDITOR are empty.")
+	editorArgs    = flag.String("editor_args", "{file}", "Arguments to pass to the editor, separated by spaces. The placeholder {file} is replaced with the path of the file to edit, and {line} with the line number to place the cursor on, e.g. \"+{line} {file}\" for vim.")
+	dir           = flag.String("dir", "", "Base directory for snippets. If empty, the value of $SNIP_DIR is used; if that is also empty, ~/.snip is used, or on Linux $XDG_DATA_HOME/snip (by default ~/.local/share/snip) unless ~/.snip already exists.")
+	timezone      = flag.String("timezone", "", "IANA name of the timezone to use, e.g. \"Europe/Stockholm\". If empty, the local timezone is used, and its name is inferred on a best-effort basis.")
+	notebook      = flag.String("notebook", "", "Name of the notebook to use. Snippets in a notebook are stored in a subdirectory of the base directory with the same name. If empty, snippets are stored directly in the base directory.")
+	ioRetries     = flag.Int("io_retries", 0, "Number of times to retry reading or writing a snippet file if it fails with a transient error, e.g. a timeout on a network filesystem, waiting twice as long before each retry.")
+	layout        = flag.String("layout", "flat", "How snippet files are stored: \"flat\" for files directly in the notebook directory (e.g. 2006-01-02.txt), or \"dir\" for a directory per file (e.g. 2006-01-02/snippets.txt), which can also hold attachments (see -attach).")
+	attach        = flag.String("attach", "", "Path of a file to attach to the snippet, with -layout=dir. The file is copied into the directory of the snippet file, and referred to on a line below the snippet.")
+	granularity   = flag.String("granularity", "day", "How snippets are grouped into files: \"day\" (e.g. 2006-01-02.txt), \"week\" (ISO week, e.g. 2006-W01.txt), or \"month\" (e.g. 2006-01.txt).")
+	tags          tagList
+	dayStart      timeOfDay
+	fileMode      = permFlag(0o600)
+	dirMode       = permFlag(0o755)
+	subheaders    = flag.String("subheaders", "", "Group snippets under divider lines like \"-- 14:00 --\". The only supported value is \"hour\", which adds a divider whenever the snippet is in a different hour than the previous one. If empty, snippet files are kept flat.")
+	ago           = flag.Duration("ago", 0, "How long ago the snippet happened, e.g. \"15m\" or \"1h30m\". The timestamp and the snippet file are based on the current time minus this duration.")
+	at            = flag.String("at", "", "Time the snippet happened, in the format of -include_time (e.g. \"09:15\"), optionally preceded by a date and a space (e.g. \"yesterday 09:15\"). The timestamp and the snippet file are based on it instead of the current time.")
+	appendTo      = flag.String("append_to", "", "Date (YYYY-MM-DD) of the snippet file to add the snippet to, e.g. to add a forgotten snippet to yesterday's file. Defaults to today. The timestamp prefix still uses the current time.")
+	template      = flag.String("template", "", "Name of a template to pre-fill the editor with, which is read from templates/<name>.txt in the base directory. Implies -edit.")
+	multiline     = flag.Bool("multiline", false, "Preserve line breaks in the snippet, by writing all lines but the first as indented continuation lines. By default, newlines are replaced with spaces.")
+	maxLength     = flag.Int("max_length", 0, "Maximum length of the snippet text in characters (runes), excluding the timestamp. Longer snippets are rejected. 0 means no limit.")
+	wordCount     = flag.Bool("word_count", false, "Append the number of words in the snippet to it, e.g. \" (42 words)\".")
+	checkOrder    = flag.Bool("check_order", false, "Warn if the new snippet's timestamp is earlier than that of the last snippet in the snippet file, e.g. because the system clock went backwards. Snippets whose timestamps can't be parsed are ignored.")
+	strictOrder   = flag.Bool("strict_order", false, "Like -check_order, but refuse to add the snippet instead of warning.")
+	insertSorted  = flag.Bool("insert_sorted", false, "Insert the snippet before the first snippet in the snippet file with a later timestamp, instead of at the end, to keep the file in chronological order when backdating with -at or -ago. Snippets whose timestamps can't be parsed keep their positions.")
+	expandEnv     = flag.Bool("expand_env", false, "Expand environment variables like $VAR or ${VAR} in -m. Use $$ for a literal $. Undefined variables expand to the empty string.")
+	strictExpand  = flag.Bool("strict_expand", false, "With -expand_env, fail if -m refers to an undefined environment variable.")
+	prepend       = flag.Bool("prepend", false, "Add the snippet above the existing snippets, right after the header, instead of at the end, e.g. for notebooks kept in reverse chronological order.")
+	attribute     = flag.Bool("attribute", false, "Append the author of the snippet to it as \"@name\", e.g. for notebooks shared with a team. The author is -author.")
+	author        = flag.String("author", "", "Name of the author to attribute snippets to with -attribute. Defaults to $USER.")
+	raw           = flag.Bool("raw", false, "Keep the snippet verbatim instead of joining its lines: the first line (or -m) is the title, and the remaining lines are stored as an indented code block, preserving line breaks, blank lines, and leading whitespace. Useful for shell commands and stack traces.")
+	cont          = flag.Bool("continue", false, "Add the snippet to the end of the last snippet in the snippet file, separated by \"; \", instead of adding it as a new line. If there is no previous snippet, it's added as usual.")
+	dedup         = flag.Bool("dedup", false, "Skip adding the snippet if its text (ignoring the timestamp prefix) is identical to the last snippet in the file.")
+	editLast      = flag.Bool("edit_last", false, "Open $EDITOR to edit the last snippet in today's snippet file, instead of adding a new snippet.")
+	retryOnEmpty  = flag.Bool("retry_on_empty", false, "If the snippet is left empty in $EDITOR, offer to reopen the editor instead of aborting.")
+	toStdout      = flag.Bool("stdout", false, "Print the formatted snippet line to stdout instead of adding it to a snippet file. No header is printed.")
+	dryRun        = flag.Bool("dry_run", false, "Print the path of the snippet file and the contents it would have to stdout, instead of writing it.")
+	hook          = flag.String("hook", "", "Path of a command to transform each snippet line, including its timestamp, before it's written. The line is written to the command's stdin, and its stdout is used instead. If the command fails, the snippet isn't written.")
+	encrypt       = flag.Bool("encrypt", false, "Store snippet files encrypted with age (https://age-encryption.org), which must be installed, as e.g. 2006-01-02.txt.age. Requires -age_recipients and -age_identity.")
+	ageRecipients = flag.String("age_recipients", "", "Path of the age recipients file to encrypt snippet files to, with -encrypt.")
+	ageIdentity   = flag.String("age_identity", "", "Path of the age identity file to decrypt snippet files with, with -encrypt.")
+	noAtomic      = flag.Bool("no_atomic", false, "If atomically replacing a snippet file fails because the filesystem doesn't support it, e.g. on some FUSE or network mounts, fall back to overwriting the file in place, with a warning.")
+	showVersion   = flag.Bool("version", false, "Print the version of snip and exit.")
+	color         = flag.String("color", "auto", "When to colorize the output of list and search: \"auto\" (if stdout is a terminal and $NO_COLOR isn't set), \"always\", or \"never\".")
+	quiet         = flag.Bool("quiet", false, "Don't log warnings and other non-fatal messages to stderr. Errors are still logged.")
+	batch         = flag.String("batch", "", "Path of a file to read snippets from, one per line, instead of -m, stdin, or the editor. Each non-blank line is added as a separate snippet with its own timestamp, in a single write to the snippet file.")
+	printPath     = flag.Bool("print_path", false, "After adding the snippet, print the absolute path of the snippet file (and nothing else) to stdout, e.g. for editor integrations.")
+	deleteLast    = flag.Bool("delete_last", false, "Delete the last snippet in today's snippet file, instead of adding a new snippet.")
+	refresh       = flag.Bool("refresh_header", false, "With -edit_last or the open subcommand, replace the header line of the snippet file, if any, with one freshly formatted according to -header_format, e.g. to fix a wrongly inferred timezone.")
+	backup        = flag.Bool("backup", false, "Before -edit_last or -delete_last rewrites a snippet file, save its current contents to a file with the same name plus \".bak\", so that a botched edit can be recovered.")
+	priority      = flag.String("priority", "", "Priority to mark the snippet with, as a marker at the start of its text: \"high\" for \"[!]\", \"med\" for \"[~]\", or \"low\" for \"[.]\". Snippets can be filtered by priority with list -priority.")
+	createDir     = flag.Bool("create_dir", true, "Create the base directory and the notebook directory if they don't exist. With -create_dir=false, adding a snippet to a directory that doesn't exist is an error instead, e.g. to catch typos in -dir or -notebook in scripts.")
+	normalize     = flag.String("normalize", "", "Unicode normalization to apply to snippets before writing them. The only supported value is \"nfc\", which stores accented characters in composed form, e.g. for text pasted from browsers. If empty, snippets are stored as is.")
+	link          = flag.String("link", "", "URL to record, e.g. of an article read. The snippet is a Markdown link to it, like \"[title](URL)\", with -m as the title. Without -m, the snippet is the URL itself, or with -fetch_title, a link with the title of the page.")
+	fetchTitle    = flag.Bool("fetch_title", false, "With -link and without -m, fetch the page and use its title as the title of the link. If fetching it fails or takes too long, the URL is used instead.")
+	bullets       = flag.Bool("bullets", false, "Write the lines of the snippet after the first as indented list items below it, like \"  - item\", e.g. for pasted bullet points. Blank lines are dropped, and existing list markers like \"*\" are replaced with \"-\".")
+	ifChanged     = flag.Bool("if_changed", false, "Don't rewrite the snippet file if its new contents would be identical to the existing ones, and log \"No changes\" instead, so that e.g. sync tools watching the snippet files aren't triggered. Applies to -edit_last and the edit subcommand.")
+)
+
+// subcommands maps the names of subcommands to the functions implementing them.
+// Each function gets the arguments following the name of the subcommand.
+var subcommands map[string]func(args []string) error
+
+// subcommandNames returns the names of all subcommands, sorted.
+func subcommandNames() []string {
+	return slices.Sorted(maps.Keys(subcommands))
+}
+
+func init() {
+	// This is populated here rather than in the declaration to avoid an
+	// initialization cycle, since some subcommands refer to it.
+	subcommands = map[string]func(args []string) error{
+		"add":        runAdd,
+		"completion": runCompletion,
+		"count":      runCount,
+		"digest":     runDigest,
+		"doctor":     runDoctor,
+		"edit":       runEdit,
+		"export":     runExport,
+		"grep-day":   runGrepDay,
+		"help":       runHelp,
+		"import":     runImport,
+		"last":       runLast,
+		"list":       runList,
+		"migrate":    runMigrate,
+		"open":       runOpen,
+		"path":       runPath,
+		"recent":     runRecent,
+		"search":     runSearch,
+		"stats":      runStats,
+		"tags":       runTags,
+		"undo":       runUndo,
+		"verify":     runVerify,
+		"watch":      runWatch,
+		"wordcount":  runWordcount,
+	}
+	flag.Usage = usage
+	flag.Var(&dayStart, "day_start", "Time of day (HH:MM) when a new day starts, for choosing which snippet file to write to. For example, with \"03:00\" snippets recorded before 3am are added to the previous date's file. Timestamps still show the actual time.")
+	flag.Var(&fileMode, "file_mode", "Permissions, in octal, for snippet files written by snip.")
+	flag.Var(&dirMode, "dir_mode", "Permissions, in octal, for directories created by snip.")
+	flag.Var(&tags, "tag", "Tag to add to the snippet, as \"#tag\" at the end of the line. Can be repeated.")
+}
+
+var (
+	// errEmptySnippet is returned when the snippet is empty, e.g. because -m
+	// was empty and nothing was piped to stdin.
+	errEmptySnippet = errors.New("snippet is empty")
+	// errSnippetLeftEmpty is returned when the user left the snippet empty in
+	// the editor, which is how to abort writing a snippet.
+	errSnippetLeftEmpty = errors.New("aborting: snippet left empty")
 )
 
 // baseDir returns the base directory for everything related to snip (snippets
-// and, potentially in the future, config).
+// and config). The -dir flag takes precedence over the SNIP_DIR environment
+// variable, which takes precedence over the default (see defaultBaseDir).
 func baseDir() (string, error) {
+	if d := cmp.Or(*dir, os.Getenv("SNIP_DIR")); d != "" {
+		return d, nil
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return "", fmt.Errorf("resolve snip dir: %v", err)
+		return "", fmt.Errorf("resolve snip dir: %w", err)
 	}
-	return filepath.Join(home, ".snip"), nil
+	return defaultBaseDir(runtime.GOOS, home, os.Getenv("XDG_DATA_HOME")), nil
 }
 
-// snippetPath is the file path where a snippet timestamped at t should be
-// written to.
-func snippetPath(t time.Time) (string, error) {
-	if t.IsZero() {
-		return "", fmt.Errorf("resolve snippet path: timestamp is zero")
+// defaultBaseDir returns the default base directory on the given OS, for the
+// given home directory. On Linux, that's $XDG_DATA_HOME/snip (by default
+// ~/.local/share/snip), following the XDG Base Directory Specification, unless
+// ~/.snip already exists, so that existing setups keep working. Elsewhere, it's
+// ~/.snip.
+func defaultBaseDir(goos, home, xdgDataHome string) string {
+	legacy := filepath.Join(home, ".snip")
+	if goos != "linux" {
+		return legacy
+	}
+	if info, err := os.Stat(legacy); err == nil && info.IsDir() {
+		return legacy
+	}
+	// The specification says that relative paths should be ignored.
+	if xdgDataHome == "" || !filepath.IsAbs(xdgDataHome) {
+		xdgDataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(xdgDataHome, "snip")
+}
+
+// checkBaseDir returns an error if the base directory exists but isn't a
+// directory, e.g. because of a misconfiguration or a botched sync. Otherwise
+// the error would be a cryptic one from trying to create or read files in it.
+func checkBaseDir() error {
+	base, err := baseDir()
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(base)
+	if err != nil {
+		// Most likely it doesn't exist, which is fine. Any other problems
+		// will be reported when trying to use it.
+		return nil
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s exists but is not a directory; please move or remove it", base)
+	}
+	return nil
+}
+
+// checkSnippetDir returns an error if the snippet directory doesn't exist and
+// -create_dir=false, rather than letting it be created. It's only checked
+// before adding snippets, so that e.g. listing snippets still works.
+func checkSnippetDir() error {
+	if *createDir {
+		return nil
+	}
+	dir, err := snippetDir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("-create_dir=false, but the snippet directory can't be used: %w", err)
 	}
-	t = t.Local()
+	return nil
+}
+
+// snippetDir returns the directory containing the snippet files of the
+// notebook given by -notebook, which is the base directory itself for the
+// default notebook.
+func snippetDir() (string, error) {
 	base, err := baseDir()
 	if err != nil {
-		return "", fmt.Errorf("resolve snippet path: %v", err)
+		return "", err
 	}
-	return filepath.Join(base, t.Format(time.DateOnly)+".txt"), nil
+	if nb := *notebook; nb != "" {
+		if err := snip.CheckFileName(nb); err != nil {
+			return "", fmt.Errorf("invalid notebook: %w", err)
+		}
+		return filepath.Join(base, nb), nil
+	}
+	return base, nil
 }
 
-// inferLocalTimezone attempts to figure out the IANA name of the local timezone
-// (e.g. "Europe/Stockholm" or "America/Los_Angeles"). It's done on best effort
-// basis, since macOS doesn't provide any explicit way to query for it.
-//
-// This function uses the value of the TZ environment variable, if set, as long
-// as it is a valid location according to [time.LoadLocation].
-func inferLocalTimezone() (string, error) {
-	// Let the TZ environment variable take precedence, if it's set and resolves
-	// to a valid timezone using [time.LoadLocation].
-	if tz := os.Getenv("TZ"); tz != "" {
-		if _, err := time.LoadLocation(tz); err == nil { // if NO error
-			return tz, nil
+// flagOutput is where the flag sets of the subcommands print their usage and
+// errors. If nil, they print to stderr.
+var flagOutput io.Writer
+
+// newFlagSet returns a new flag set for the subcommand with the given name,
+// which prints to flagOutput and exits on errors.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.SetOutput(flagOutput)
+	return fs
+}
+
+// notebookFlag defines a -notebook flag in a subcommand's flag set, which
+// overrides the global -notebook flag, including which notebook config is
+// loaded.
+func notebookFlag(fs *flag.FlagSet) {
+	fs.Func("notebook", "Name of the notebook to use. Overrides the global -notebook flag.", func(value string) error {
+		*notebook = value
+		if err := loadNotebookConfig(); err != nil {
+			return err
 		}
+		return validateFlags()
+	})
+}
+
+// snippetPath is the file path where a snippet timestamped at t should be
+// written to. The name of the file depends on the -granularity and -encrypt
+// flags.
+func snippetPath(t time.Time) (string, error) {
+	dir, err := snippetDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve snippet path: %w", err)
 	}
-	// Best-effort: assume that /etc/localtime is a symlink to a file whose path
-	// contains the timezone name in a standardized format. On my macOS system,
-	// it looks like this:
-	//
-	//     $ readlink /etc/localtime
-	//     /var/db/timezone/zoneinfo/Europe/London
-	//
-	// To be a bit more liberal in the paths accepted, look for a "zoneinfo/"
-	// substring, and assume everything after it is the timezone name.
-	//
-	// As a sanity check, try loading the inferred timezone with
-	// [time.LoadLocation]. If that doesn't work, return an error.
-	const localtime = "/etc/localtime"
-	realPath, err := filepath.EvalSymlinks(localtime)
+	path, err := snip.SnippetPath(dir, snip.Granularity(*granularity), t)
 	if err != nil {
-		return "", fmt.Errorf("infer local timezone: evaluate %s as a symlink: %w", localtime, err)
+		return "", err
 	}
-	const marker = "zoneinfo/"
-	idx := strings.Index(realPath, marker)
-	if idx == -1 {
-		return "", fmt.Errorf("infer local timezone: infer from %s symlink: real path does not contain %q", localtime, marker)
+	path = snip.LayoutPath(path, snip.Layout(*layout))
+	if *encrypt {
+		path += snip.EncryptedExt
 	}
-	inferred := realPath[idx+len(marker):]
-	if _, err := time.LoadLocation(inferred); err != nil {
-		return "", fmt.Errorf("infer local timezone: infer from %s symlink: inferred timezone %q cannot be loaded with time.LoadLocation: %w", localtime, inferred, err)
+	return path, nil
+}
+
+// snippetFiles returns the paths of all snippet files in the current notebook,
+// sorted chronologically (see [snip.Files]).
+func snippetFiles() ([]string, error) {
+	dir, err := snippetDir()
+	if err != nil {
+		return nil, fmt.Errorf("find snippet files: %w", err)
 	}
-	return inferred, nil
+	return snip.Files(dir)
+}
+
+// snippetLines returns the snippets in the contents of a snippet file,
+// excluding the header and blank lines. Usually each snippet is a single line,
+// but snippets written with -multiline also include their continuation lines
+// (separated by newlines).
+func snippetLines(contents []byte) [][]byte {
+	return snip.Lines(contents, *headerPrefix)
+}
+
+// stdinIsTerminal reports whether stdin is connected to a terminal, as opposed
+// to e.g. a pipe or a regular file.
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		// Assume a terminal, which is the historical behavior.
+		return true
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// localTimezone returns the result of resolveTimezone. It's only resolved once
+// per process, since inferring the timezone involves evaluating symlinks and
+// loading timezone data, and the result won't change while snip is running.
+var localTimezone = sync.OnceValues(resolveTimezone)
+
+// inferTimezone infers the name of the local timezone. It's a variable so that
+// tests can replace it.
+var inferTimezone = snip.InferLocalTimezone
+
+// resolveTimezone returns the name of the timezone given by -timezone, or if
+// that is empty, the result of inferTimezone.
+func resolveTimezone() (string, error) {
+	if tz := *timezone; tz != "" {
+		return tz, nil
+	}
+	return inferTimezone()
+}
+
+// setTimezone makes the timezone given by -timezone, if any, the local
+// timezone, so that it's used for everything: timestamps, headers, and
+// choosing which snippet file to write to.
+func setTimezone() error {
+	tz := *timezone
+	if tz == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return usageErrorf("invalid -timezone %q: %w", tz, err)
+	}
+	time.Local = loc
+	return nil
 }
 
 func run() error {
-	openEditor := *edit
-	if *message == "" {
-		openEditor = true
+	if *batch != "" {
+		return runBatch()
+	}
+
+	useEditor := *edit
+	if *message == "" && *link == "" {
+		useEditor = true
 	}
 
-	// Create a temporary file to hold the snippet before it's committed to the
-	// snipdir.
-	tmpFile, err := os.CreateTemp("", "")
+	// Start out with the title from -m, if any. Newlines are replaced with
+	// spaces right away, so that the title is guaranteed to be on a single line
+	// regardless of what's added to it below. If -m refers to a file, its
+	// contents are used like a piped snippet instead, i.e. the first line is
+	// the title, and the rest is formatted according to -multiline, -raw or
+	// -bullets.
+	title, fromFile, err := readMessage(*message)
 	if err != nil {
-		return fmt.Errorf("create temporary file for editing snippet: %v", err)
+		return err
 	}
-	defer func() {
-		if err := os.Remove(tmpFile.Name()); err != nil {
-			log.Printf("Deleting temporary file for editing snippet unexpectedly failed: %v", err)
+	if *expandEnv {
+		title, err = expandEnvVars(title)
+		if err != nil {
+			return err
 		}
-	}()
+	}
+	if !fromFile {
+		title = strings.ReplaceAll(title, "\n", " ")
+	}
+	if *link != "" {
+		title = linkText(title, *link)
+	}
+	snippet := []byte(title)
 
-	// If there is a snippet title prefilled, write it to the temporary file.
-	if m := *message; m != "" {
-		if _, err := tmpFile.WriteString(m); err != nil {
-			return fmt.Errorf("write title from -m to temporary file: %v", err)
+	// If a template is given, add it after the title and make sure the editor
+	// is opened to fill it in.
+	if name := *template; name != "" {
+		t, err := loadTemplate(name)
+		if err != nil {
+			return err
 		}
+		if len(snippet) != 0 {
+			snippet = append(snippet, '\n')
+		}
+		snippet = append(snippet, t...)
+		useEditor = true
 	}
 
-	// Optionally have the user edit the snippet in their editor before reading
-	// it back.
-	if openEditor {
-		editor := cmp.Or(os.Getenv("EDITOR"), "vim")
-		cmd := exec.Command(editor, tmpFile.Name())
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("open $EDITOR to edit snippet: %v", err)
+	// If stdin isn't a terminal, something is being piped into snip, so read
+	// the snippet body from there instead of opening the editor. The body goes
+	// after the title from -m, if any.
+	if !stdinIsTerminal() {
+		useEditor = false
+		body, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read snippet from stdin: %w", err)
+		}
+		if len(snippet) != 0 {
+			snippet = append(snippet, '\n')
 		}
+		snippet = append(snippet, body...)
 	}
 
-	// Read the snippet back from the temporary file. After this point, we don't
-	// care about the temporary file anymore.
-	snippet, err := os.ReadFile(tmpFile.Name())
-	if err != nil {
-		return fmt.Errorf("read temporary file after editing: %v", err)
+	// With -multiline or -bullets, end the title from -m with a newline, so
+	// that the cursor is placed on the line after it (if the editor supports
+	// it, see -editor_args), ready for writing the body.
+	if useEditor && (*multiline || *bullets) && *template == "" && len(snippet) != 0 {
+		snippet = append(snippet, '\n')
+	}
+
+	// Optionally have the user edit the snippet in their editor.
+	if useEditor {
+		for {
+			var err error
+			snippet, err = editInTempFile(snippet)
+			if err != nil {
+				return err
+			}
+			if len(bytes.TrimSpace(snippet)) != 0 || !*retryOnEmpty || !confirm("Snippet is empty. Reopen editor?") {
+				break
+			}
+		}
 	}
 	snippet = bytes.TrimSpace(snippet)
 	if len(snippet) == 0 {
-		return fmt.Errorf("snippet is empty")
-	}
-	// Replace all newlines with spaces, so that each snippet is only on one line.
-	snippet = bytes.ReplaceAll(snippet, []byte{'\n'}, []byte{' '})
-	// Add a trailing newline.
-	snippet = append(snippet, '\n')
-	// TODO: add future processing, such as validation, here.
-
-	// Optionally write the current timestamp as the first part of the snippet.
-	now := time.Now().Local()
-	if layout := *includeTime; layout != "" {
-		snippet = append([]byte(now.Format(layout)), snippet...)
-	}
-
-	// Assemble the final snippet file and write it out to disk, creating any
-	// directories required. To prevent 0-byte or half-written snippet files,
-	// write out the result to a temporary file and then atomically move it into
-	// place using the github.com/google/renamio package. This might seem
-	// excessive for something that's just personal notes stored locally, but
-	// for the author of this program these snippets are very valuable, so it's
-	// worth being a bit paranoid.
-	//
-	// The final snippet file should include:
-	// * The header, if -include_header=true.
-	//     * If the snippet file already includes a header, but
-	//       -include_header=false in this invocation, leave the header there
-	//       i.e. don't remove it.
-	// * Any existing snippet lines.
-	// * The new snippet line.
+		if useEditor {
+			return errSnippetLeftEmpty
+		}
+		return errEmptySnippet
+	}
+	_, err = writeSnippets([][]byte{snippet})
+	return err
+}
+
+// writeSnippets formats the texts of new snippets, which must be trimmed and
+// non-empty, and adds them to their snippet file in a single write. It returns
+// the number of snippets added.
+func writeSnippets(texts [][]byte) (n int, err error) {
+	// Optionally write the current timestamp (minus -ago, if given), or the
+	// time given by -at, as the first part of each snippet.
+	now, err := snippetTime()
+	if err != nil {
+		return 0, err
+	}
+	lines := make([][]byte, 0, len(texts))
+	for _, snippet := range texts {
+		snippet = formatSnippetText(snippet)
+		// With -raw, the tags and the word count go on the title line,
+		// rather than after the code block.
+		var block []byte
+		if *raw {
+			if i := bytes.IndexByte(snippet, '\n'); i != -1 {
+				snippet, block = snippet[:i:i], snippet[i:]
+			}
+		}
+		if *priority != "" {
+			snippet = prependPriority(snippet, *priority)
+		}
+		snippet = appendTags(snippet, tags)
+		if *attribute {
+			name, err := resolveAuthor()
+			if err != nil {
+				return 0, err
+			}
+			snippet = appendAuthor(snippet, name)
+		}
+		// The word count goes last, so that it's recognized at the end of
+		// the line by wordCountRE.
+		if *wordCount {
+			snippet = appendWordCount(snippet)
+		}
+		snippet = append(snippet, block...)
+		if n := utf8.RuneCount(snippet); *maxLength > 0 && n > *maxLength {
+			return 0, fmt.Errorf("snippet is %d characters long, which is more than -max_length %d", n, *maxLength)
+		}
+		if *includeTime != "" && !*noTimestamp {
+			snippet = append([]byte(timestampPrefix(now)), snippet...)
+		}
+		if *hook != "" {
+			var err error
+			snippet, err = runHook(snippet)
+			if err != nil {
+				return 0, err
+			}
+		}
+		lines = append(lines, normalizeText(snippet))
+	}
+
+	// With -stdout, snip is only used as a formatter, so there's no need to
+	// touch the snippet files at all.
+	if *toStdout {
+		for _, line := range lines {
+			if _, err := os.Stdout.Write(append(line, '\n')); err != nil {
+				return 0, fmt.Errorf("write snippet to stdout: %w", err)
+			}
+		}
+		return len(lines), nil
+	}
 
 	// Write the snippet out to its file, potentially creating all necessary
 	// directories in its path first. If the file already exists, the snippet
-	// will be added at the bottom.
-	path, err := snippetPath(now)
-	if err != nil {
-		return fmt.Errorf("write snippet out to file: %v", err)
-	}
-	if err := os.MkdirAll(filepath.Dir(path), fs.FileMode(0o755)); err != nil {
-		return fmt.Errorf("write snippet out to file: ensure directory exists: %v", err)
-	}
-
-	// If the snippet file already exists, read it back in. We might need to add
-	// the header, and we need to include any existing snippet lines.
-	existing, err := os.ReadFile(path)
-	if errors.Is(err, os.ErrNotExist) {
-		// The file doesn't exist, which is fine, just initialize with empty
-		// contents.
-		existing = nil
-	} else if err != nil {
-		// Some other error occurred and we don't know how to handle it.
-		return fmt.Errorf("write snippet out to file: read existing snippets: %v", err)
-	}
-	var assembled bytes.Buffer
-
-	// The only time we need to format the header and write it out is if
-	// -include_header=true and the file doesn't already contain a header. In
-	// all other cases, we don't need to do anything:
-	// * -include_header=true  && contains header        => do nothing
-	// * -include_header=false && contains header        => do nothing
-	// * -include_header=false && doesn't contain header => do nothing
-	// We won't try to parse the header into a date, as that is too fragile.
-	// Instead we simply look for whether the file starts with "---", which we
-	// use as a proxy for "does the file contain the header".
-	if *includeHeader && !bytes.HasPrefix(existing, []byte("---")) {
-		timezone, err := inferLocalTimezone()
+	// will be added at the bottom. The file is normally today's, unless
+	// -append_to says otherwise.
+	// The same fileTime is used for both the name of the snippet file and the
+	// header, so that they always agree on the date. It's adjusted for
+	// -day_start, unlike the timestamp.
+	fileTime := dayOf(now)
+	if d := *appendTo; d != "" {
+		var err error
+		fileTime, err = parseDateFlag("append_to", d)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := checkBaseDir(); err != nil {
+		return 0, err
+	}
+	a, err := newAppender()
+	if err != nil {
+		return 0, fmt.Errorf("write snippet out to file: %w", err)
+	}
+	// Copy the attachment first, so that the snippet never refers to a
+	// missing file. If adding the snippet fails, the copy is removed again.
+	var res snip.AppendResult
+	if src := *attach; src != "" {
+		var path, name string
+		path, err = snippetPath(fileTime)
+		if err != nil {
+			return 0, err
+		}
+		name, err = attachmentName(path, src)
 		if err != nil {
-			log.Printf("Failed to infer local timezone: %v", err)
-			timezone = "<unknown timezone>"
+			return 0, err
+		}
+		for i := range lines {
+			lines[i] = fmt.Appendf(lines[i], "\n%s%s%s", snip.ContinuationIndent, attachmentPrefix, name)
+		}
+		if !*dryRun {
+			if err := copyAttachment(path, src, name); err != nil {
+				return 0, err
+			}
+			// err is the result, so the copy is also removed if the write
+			// is rejected, e.g. by -strict_order.
+			defer func() {
+				if err != nil || res.Appended == 0 {
+					os.Remove(filepath.Join(filepath.Dir(path), name))
+				}
+			}()
 		}
-		headerFormat := "--- Monday Jan _2 2006 in " + timezone + " ---"
-		assembled.WriteString(now.Format(headerFormat) + "\n")
 	}
+	// With -insert_sorted, the snippet can't end up out of order, so there's
+	// nothing to check.
+	if (*checkOrder || *strictOrder) && !*insertSorted && *includeTime != "" && !*noTimestamp {
+		onWrite := a.OnWrite
+		a.OnWrite = func(path string, previous []byte) error {
+			if err := checkSnippetOrder(path, previous, now); err != nil {
+				return err
+			}
+			return onWrite(path, previous)
+		}
+	}
+	var insertBefore func(line []byte) bool
+	if *prepend {
+		// Insert before the first snippet, if any.
+		insertBefore = func([]byte) bool { return true }
+	} else if *insertSorted && *includeTime != "" && !*noTimestamp {
+		insertBefore, err = snippetsAfter(fileTime, now)
+		if err != nil {
+			return 0, err
+		}
+	}
+	res, err = a.Append(context.Background(), snip.AppendOptions{
+		Lines:   lines,
+		Time:    fileTime,
+		Divider: subheader(now),
+		// With -dedup, skip the snippet if it's identical to the last one,
+		// e.g. because snip was accidentally run twice with the same -m.
+		Dedup:        *dedup,
+		Continue:     *cont,
+		InsertBefore: insertBefore,
+		DryRun:       *dryRun,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if n := res.Duplicates; n != 0 {
+		slog.Info("Skipped duplicate snippets", "count", n)
+	}
+	if *dryRun && res.Appended != 0 {
+		fmt.Printf("Would write to %s:\n", res.Path)
+		if _, err := os.Stdout.Write(res.Contents); err != nil {
+			return 0, fmt.Errorf("dry run: %w", err)
+		}
+	}
+	if *printPath && !*dryRun {
+		// The base directory can be relative, e.g. if -dir is.
+		abs, err := filepath.Abs(res.Path)
+		if err != nil {
+			return 0, fmt.Errorf("print path: %w", err)
+		}
+		fmt.Println(abs)
+	}
+	return res.Appended, nil
+}
+
+// subheader returns the divider line for a snippet timestamped at t, according
+// to -subheaders, or the empty string if snippets aren't grouped.
+func subheader(t time.Time) string {
+	switch *subheaders {
+	case "hour":
+		return snip.FormatDivider(fmt.Sprintf("%02d:00", t.Hour()))
+	default:
+		return ""
+	}
+}
 
-	// Include the existing snippets, if any.
-	assembled.Write(existing)
-	// In case the existing snippets didn't contain a newline, write one out, so
-	// that the new snippet is guaranteed to be on a new line. Only do this if
-	// there are already any existing snippets -- there should be no _leading_
-	// newlines in case the existing snippets are empty (e.g. because this is
-	// the first snippet of the day).
-	if n := len(existing); n != 0 && existing[n-1] != '\n' {
-		assembled.WriteByte('\n')
+// newAppender returns a [snip.Appender] for the current notebook, configured
+// by the global flags.
+func newAppender() (*snip.Appender, error) {
+	if err := checkSnippetDir(); err != nil {
+		return nil, err
+	}
+	base, err := baseDir()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := snippetDir()
+	if err != nil {
+		return nil, err
 	}
+	a := &snip.Appender{
+		Dir:               dir,
+		LockDir:           base,
+		Granularity:       snip.Granularity(*granularity),
+		Layout:            snip.Layout(*layout),
+		HeaderPrefix:      *headerPrefix,
+		Separators:        timestampSeparators(),
+		FileMode:          fs.FileMode(fileMode),
+		DirMode:           fs.FileMode(dirMode),
+		OnWrite:           recordUndo,
+		NonAtomicFallback: *noAtomic,
+		IORetries:         *ioRetries,
+	}
+	if *encrypt {
+		a.Cipher = snippetCipher()
+	}
+	// If the snippet file already contains a header, it's left there even
+	// with -include_header=false.
+	if *includeHeader {
+		a.Header = formatHeader
+	}
+	return a, nil
+}
 
-	// Finally, add the new snippet at the end. Note that we explicitly
-	// construct it to hold a newline above, so we don't need to check for/add
-	// it here.
-	assembled.Write(snippet)
+// writeFile atomically replaces the file at path with data, with the
+// permissions given by -file_mode, falling back to a non-atomic write with
+// -no_atomic. See [snip.WriteFile]. Transient errors are retried according to
+// -io_retries.
+func writeFile(path string, data []byte) error {
+	return snip.Retry(*ioRetries, func() error {
+		return snip.WriteFile(path, data, fs.FileMode(fileMode), *noAtomic)
+	})
+}
 
-	// Atomically write out the assembled contents to the snippet file.
-	if err := renameio.WriteFile(path, assembled.Bytes(), fs.FileMode(0o600)); err != nil {
-		return fmt.Errorf("write snippet out to file: %v", err)
+// readFile reads the file at path, retrying transient errors according to
+// -io_retries.
+func readFile(path string) ([]byte, error) {
+	var data []byte
+	err := snip.Retry(*ioRetries, func() error {
+		var err error
+		data, err = os.ReadFile(path)
+		return err
+	})
+	return data, err
+}
+
+// validateFlags checks the values of the global flags, so that invalid values
+// are reported before the user has spent any time writing a snippet.
+func validateFlags() error {
+	switch snip.Granularity(*granularity) {
+	case snip.Day, snip.Week, snip.Month:
+	default:
+		return usageErrorf("invalid -granularity %q: must be one of \"day\", \"week\", or \"month\"", *granularity)
+	}
+	switch snip.Layout(*layout) {
+	case snip.Flat, snip.Dir:
+	default:
+		return usageErrorf("invalid -layout %q: must be \"flat\" or \"dir\"", *layout)
+	}
+	if src := *attach; src != "" {
+		switch {
+		case snip.Layout(*layout) != snip.Dir:
+			return usageErrorf("-attach requires -layout=dir")
+		case *encrypt:
+			return usageErrorf("-attach can't be combined with -encrypt, since attachments aren't encrypted")
+		case *batch != "" || *toStdout:
+			return usageErrorf("-attach can't be combined with -batch or -stdout")
+		}
+		if fi, err := os.Stat(src); err != nil {
+			return usageErrorf("invalid -attach: %w", err)
+		} else if !fi.Mode().IsRegular() {
+			return usageErrorf("invalid -attach %q: not a regular file", src)
+		}
+	}
+	if nb := *notebook; nb != "" {
+		if err := snip.CheckFileName(nb); err != nil {
+			return usageErrorf("invalid -notebook %q: %w", nb, err)
+		}
+	}
+	if !strings.Contains(*editorArgs, "{file}") {
+		return usageErrorf("invalid -editor_args %q: must contain the placeholder {file}", *editorArgs)
+	}
+	switch *subheaders {
+	case "", "hour":
+	default:
+		return usageErrorf("invalid -subheaders %q: must be empty or \"hour\"", *subheaders)
+	}
+	switch *normalize {
+	case "", "nfc":
+	default:
+		return usageErrorf("invalid -normalize %q: must be empty or \"nfc\"", *normalize)
+	}
+	if *separator == "" || strings.ContainsAny(*separator, "\r\n") {
+		return usageErrorf("invalid -separator %q: must be non-empty and not contain newlines", *separator)
+	}
+	// Timestamps must not contain path separators, e.g. from a layout like
+	// "2006/01/02", so that they're safe to use in file names.
+	if ts := clock().Format(*includeTime); strings.ContainsAny(ts, `/\`) {
+		return usageErrorf("invalid -include_time %q: timestamp %q must not contain path separators", *includeTime, ts)
+	}
+	if s := *headerStyle; s != "line" && s != "frontmatter" {
+		return usageErrorf("invalid -header_style %q: must be \"line\" or \"frontmatter\"", s)
+	}
+	if *encrypt && (*ageRecipients == "" || *ageIdentity == "") {
+		return usageErrorf("-encrypt requires both -age_recipients and -age_identity")
+	}
+	if d := *tzDisplay; d != "name" && d != "offset" && d != "both" {
+		return usageErrorf("invalid -tz_display %q: must be \"name\", \"offset\", or \"both\"", d)
+	}
+	if *headerPrefix == "" {
+		return usageErrorf("invalid -header_prefix: must not be empty")
+	}
+	// Check with a timezone placeholder, so that we don't have to infer the
+	// local timezone just to validate the flags.
+	if h := strings.ReplaceAll(clock().Format(*headerFormat), "%tz", "Etc/UTC"); !strings.HasPrefix(h, *headerPrefix) {
+		return usageErrorf("invalid -header_format %q: header %q does not start with -header_prefix %q", *headerFormat, h, *headerPrefix)
+	}
+	if *batch != "" && (*message != "" || *edit || *template != "") {
+		return usageErrorf("-batch can't be combined with -m, -edit, or -template")
+	}
+	if *printPath && *toStdout {
+		return usageErrorf("-print_path can't be combined with -stdout, since no snippet file is written")
+	}
+	if *maxLength < 0 {
+		return usageErrorf("invalid -max_length %d: must not be negative", *maxLength)
+	}
+	if *ago < 0 {
+		return usageErrorf("invalid -ago %v: must not be negative", *ago)
+	}
+	if d := *appendTo; d != "" {
+		if _, err := parseDateFlag("append_to", d); err != nil {
+			return err
+		}
+	}
+	if *ioRetries < 0 {
+		return usageErrorf("invalid -io_retries %d: must not be negative", *ioRetries)
+	}
+	if err := validateColor(); err != nil {
+		return err
+	}
+	if *attribute {
+		if _, err := resolveAuthor(); err != nil {
+			return err
+		}
+	}
+	if *insertSorted && *cont {
+		return usageErrorf("-insert_sorted can't be combined with -continue")
+	}
+	if *prepend && (*cont || *insertSorted) {
+		return usageErrorf("-prepend can't be combined with -continue or -insert_sorted")
+	}
+	if err := validatePriority("priority", *priority); err != nil {
+		return err
+	}
+	if *priority != "" && *cont {
+		return usageErrorf("-priority can't be combined with -continue")
+	}
+	if *link != "" {
+		if err := validateLink(*link); err != nil {
+			return err
+		}
+	} else if *fetchTitle {
+		return usageErrorf("-fetch_title requires -link")
+	}
+	if *bullets && *raw {
+		return usageErrorf("-bullets can't be combined with -raw")
+	}
+	if *raw && *cont {
+		return usageErrorf("-raw can't be combined with -continue")
+	}
+	if *at != "" {
+		if *ago != 0 {
+			return usageErrorf("-at can't be combined with -ago")
+		}
+		if _, err := snippetTime(); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// setupErr is the error from loading the config and checking the global
+// flags, if any. The doctor subcommand reports it instead of failing on it,
+// since those are the kinds of problems it's there to diagnose.
+var setupErr error
+
+// setup loads the config files and checks the global flags before running a
+// subcommand.
+func setup() error {
+	if err := loadConfig(); err != nil {
+		return err
+	}
+	if *quiet {
+		slog.SetLogLoggerLevel(slog.LevelError)
+	}
+	if err := validateFlags(); err != nil {
+		return err
+	}
+	return setTimezone()
+}
+
 func main() {
 	flag.Parse()
-	if err := run(); err != nil {
+	if *showVersion {
+		fmt.Println(versionString())
+		return
+	}
+	name, args := "add", flag.Args()
+	if len(args) != 0 {
+		name, args = args[0], args[1:]
+	}
+	if err := setup(); err != nil {
+		if name != "doctor" {
+			log.Printf("Fatal error: %v", err)
+			os.Exit(exitCode(err))
+		}
+		setupErr = err
+	}
+	var err error
+	if cmd, ok := subcommands[name]; ok {
+		err = cmd(args)
+	} else {
+		flag.Usage()
+		err = usageErrorf("unknown subcommand %q", name)
+	}
+	if err != nil {
 		log.Printf("Fatal error: %v", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
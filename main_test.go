@@ -0,0 +1,382 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// setFlag sets the global flag with the given name to value for the duration
+// of the test.
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+	f := flag.Lookup(name)
+	if f == nil {
+		t.Fatalf("no flag named %q", name)
+	}
+	previous := f.Value.String()
+	if err := f.Value.Set(value); err != nil {
+		t.Fatalf("set -%s %q: %v", name, value, err)
+	}
+	t.Cleanup(func() {
+		if err := f.Value.Set(previous); err != nil {
+			t.Errorf("restore -%s %q: %v", name, previous, err)
+		}
+	})
+}
+
+// setClock pins the current time returned by clock to now for the duration of
+// the test.
+func setClock(t *testing.T, now time.Time) {
+	t.Helper()
+	previous := clock
+	clock = func() time.Time { return now }
+	t.Cleanup(func() { clock = previous })
+}
+
+func TestWriteSnippets(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		flags    map[string]string
+		wantPath string
+		want     string
+	}{
+		{
+			name:     "now",
+			wantPath: "2024-11-20.txt",
+			want:     snip.HeaderMarker + "--- Wednesday Nov 20 2024 ---\n09:15 | hello\n",
+		},
+		{
+			name:     "ago",
+			flags:    map[string]string{"ago": "10h"},
+			wantPath: "2024-11-19.txt",
+			want:     snip.HeaderMarker + "--- Tuesday Nov 19 2024 ---\n23:15 | hello\n",
+		},
+		{
+			name:     "before the start of the day",
+			flags:    map[string]string{"day_start": "10:00"},
+			wantPath: "2024-11-19.txt",
+			want:     snip.HeaderMarker + "--- Tuesday Nov 19 2024 ---\n09:15 | hello\n",
+		},
+		{
+			name:     "week",
+			flags:    map[string]string{"granularity": "week"},
+			wantPath: "2024-W47.txt",
+			want:     snip.HeaderMarker + "--- Wednesday Nov 20 2024 ---\n09:15 | hello\n",
+		},
+		{
+			name:     "markdown header",
+			flags:    map[string]string{"header_format": "# 2006-01-02", "header_prefix": "#"},
+			wantPath: "2024-11-20.txt",
+			want:     "# 2024-11-20\n09:15 | hello\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			setClock(t, time.Date(2024, time.November, 20, 9, 15, 0, 0, time.Local))
+			base := t.TempDir()
+			setFlag(t, "dir", base)
+			setFlag(t, "header_format", "--- Monday Jan _2 2006 ---")
+			for name, value := range tt.flags {
+				setFlag(t, name, value)
+			}
+			if _, err := writeSnippets([][]byte{[]byte("hello")}); err != nil {
+				t.Fatalf("writeSnippets failed: %v", err)
+			}
+			got, err := os.ReadFile(filepath.Join(base, tt.wantPath))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("snippet file %s:\n%s\nwant:\n%s", tt.wantPath, got, tt.want)
+			}
+		})
+	}
+}
+
+// setStdin replaces stdin with a pipe that yields input for the duration of
+// the test, so that snip behaves as if input was piped into it.
+func setStdin(t *testing.T, input string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	previous := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = previous
+		r.Close()
+	})
+}
+
+// readSnippets returns the contents of the snippet file for date in the base
+// directory given by -dir, without the header.
+func readSnippets(t *testing.T, date time.Time) string {
+	t.Helper()
+	path, err := snippetPath(date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, rest := splitHeader(contents)
+	return string(rest)
+}
+
+func TestRunMultilineMessage(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		flags map[string]string
+		stdin string
+		want  string
+	}{
+		{
+			name: "newlines are collapsed",
+			want: "09:15 | first second third\n",
+		},
+		{
+			name:  "newlines are collapsed with -multiline",
+			flags: map[string]string{"multiline": "true"},
+			want:  "09:15 | first second third\n",
+		},
+		{
+			name:  "piped body keeps its lines with -multiline",
+			flags: map[string]string{"multiline": "true"},
+			stdin: "body\nmore\n",
+			want:  "09:15 | first second third\n  body\n  more\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, time.November, 20, 9, 15, 0, 0, time.Local)
+			setClock(t, now)
+			setFlag(t, "dir", t.TempDir())
+			setFlag(t, "m", "first\nsecond\nthird")
+			for name, value := range tt.flags {
+				setFlag(t, name, value)
+			}
+			setStdin(t, tt.stdin)
+			if err := run(); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if got := readSnippets(t, now); got != tt.want {
+				t.Errorf("snippets:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBaseDir(t *testing.T) {
+	base := t.TempDir()
+
+	setFlag(t, "dir", base)
+	if err := checkBaseDir(); err != nil {
+		t.Errorf("checkBaseDir() with an existing directory = %v, want nil", err)
+	}
+
+	setFlag(t, "dir", filepath.Join(base, "missing"))
+	if err := checkBaseDir(); err != nil {
+		t.Errorf("checkBaseDir() with a missing directory = %v, want nil", err)
+	}
+
+	file := filepath.Join(base, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	setFlag(t, "dir", file)
+	err := checkBaseDir()
+	if want := file + " exists but is not a directory; please move or remove it"; err == nil || err.Error() != want {
+		t.Errorf("checkBaseDir() with a file = %v, want %q", err, want)
+	}
+}
+
+func TestCheckSnippetDir(t *testing.T) {
+	base := t.TempDir()
+	setFlag(t, "create_dir", "false")
+
+	setFlag(t, "dir", base)
+	if err := checkSnippetDir(); err != nil {
+		t.Errorf("checkSnippetDir() with -create_dir=false and an existing directory = %v, want nil", err)
+	}
+
+	setFlag(t, "notebook", "work")
+	if err := checkSnippetDir(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkSnippetDir() with -create_dir=false and a missing notebook = %v, want an error wrapping %v", err, os.ErrNotExist)
+	}
+	setFlag(t, "create_dir", "true")
+	if err := checkSnippetDir(); err != nil {
+		t.Errorf("checkSnippetDir() with a missing notebook = %v, want nil", err)
+	}
+}
+
+func TestCreateDirFalse(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	_, stderr, code := runSnip(t, "-dir", missing, "-create_dir=false", "-m", "hello")
+	if code == 0 || !strings.Contains(stderr, "-create_dir=false") {
+		t.Errorf("adding a snippet with -create_dir=false and a missing -dir: exit code %d, stderr %q; want an error", code, stderr)
+	}
+	if _, err := os.Stat(missing); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("%s was created with -create_dir=false (stat error: %v)", missing, err)
+	}
+
+	// Reading snippets doesn't need the directory to exist.
+	for _, args := range [][]string{
+		{"help"},
+		{"list"},
+		{"search", "hello"},
+		{"stats"},
+	} {
+		_, stderr, code := runSnip(t, append([]string{"-dir", missing, "-create_dir=false"}, args...)...)
+		if strings.Contains(stderr, "-create_dir=false") {
+			t.Errorf("snip %s with -create_dir=false and a missing -dir: exit code %d, stderr %q; want no -create_dir error", strings.Join(args, " "), code, stderr)
+		}
+	}
+}
+
+// TestMain runs snip itself instead of the tests if $SNIP_TEST_MAIN is set, so
+// that tests can run it in a subprocess with runSnip.
+func TestMain(m *testing.M) {
+	if os.Getenv("SNIP_TEST_MAIN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runSnip runs snip with the given arguments in a subprocess, with a
+// temporary home directory and an empty stdin, and returns what it wrote to
+// stdout and stderr and its exit code.
+func runSnip(t *testing.T, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "SNIP_TEST_MAIN=1", "HOME="+t.TempDir(), "SNIP_DIR=", "XDG_DATA_HOME=", "TZ=UTC")
+	var out, errOut strings.Builder
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return out.String(), errOut.String(), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatalf("run snip %q: %v", args, err)
+	}
+	return out.String(), errOut.String(), 0
+}
+
+func TestQuiet(t *testing.T) {
+	base := t.TempDir()
+	if err := os.WriteFile(filepath.Join(base, "2024-11-20.txt"), []byte("10:00 | later\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The snippet is earlier than the last one, so -check_order warns.
+	outOfOrder := []string{"-dir", base, "-check_order", "-at", "2024-11-20 09:00", "-m", "earlier"}
+
+	_, stderr, code := runSnip(t, outOfOrder...)
+	if code != 0 || !strings.Contains(stderr, "Snippet is out of order") {
+		t.Errorf("without -quiet: exit code %d, stderr %q; want 0 and a warning", code, stderr)
+	}
+
+	_, stderr, code = runSnip(t, append([]string{"-quiet"}, outOfOrder...)...)
+	if code != 0 || stderr != "" {
+		t.Errorf("with -quiet: exit code %d, stderr %q; want 0 and no output", code, stderr)
+	}
+
+	// Fatal errors are still printed.
+	_, stderr, code = runSnip(t, "-quiet", "-dir", base, "last", "-n", "0")
+	if code != exitUsage || !strings.Contains(stderr, "Fatal error: last: invalid -n 0") {
+		t.Errorf("with -quiet and an error: exit code %d, stderr %q; want %d and the error", code, stderr, exitUsage)
+	}
+}
+
+func TestDefaultBaseDir(t *testing.T) {
+	home := t.TempDir()
+	withLegacy := t.TempDir()
+	if err := os.Mkdir(filepath.Join(withLegacy, ".snip"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name        string
+		goos        string
+		home        string
+		xdgDataHome string
+		want        string
+	}{
+		{name: "linux default", goos: "linux", home: home, want: filepath.Join(home, ".local", "share", "snip")},
+		{name: "linux XDG_DATA_HOME", goos: "linux", home: home, xdgDataHome: "/data", want: filepath.Join("/data", "snip")},
+		{name: "linux relative XDG_DATA_HOME", goos: "linux", home: home, xdgDataHome: "data", want: filepath.Join(home, ".local", "share", "snip")},
+		{name: "linux existing ~/.snip", goos: "linux", home: withLegacy, xdgDataHome: "/data", want: filepath.Join(withLegacy, ".snip")},
+		{name: "darwin", goos: "darwin", home: home, xdgDataHome: "/data", want: filepath.Join(home, ".snip")},
+		{name: "windows", goos: "windows", home: home, want: filepath.Join(home, ".snip")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaultBaseDir(tt.goos, tt.home, tt.xdgDataHome); got != tt.want {
+				t.Errorf("defaultBaseDir(%q, %q, %q) = %q, want %q", tt.goos, tt.home, tt.xdgDataHome, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedup(t *testing.T) {
+	base := t.TempDir()
+	path := filepath.Join(base, "2024-11-20.txt")
+	const contents = "09:00 | same\n"
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		quiet      []string
+		wantStderr string
+	}{
+		{wantStderr: "INFO Skipped duplicate snippets count=1\n"},
+		{quiet: []string{"-quiet"}, wantStderr: ""},
+		// Flags given after "add" are applied the same way.
+		{quiet: []string{"add", "-quiet"}, wantStderr: ""},
+	} {
+		args := append([]string{"-dir", base}, tt.quiet...)
+		args = append(args, "-dedup", "-at", "2024-11-20 09:30", "-m", "same")
+		_, stderr, code := runSnip(t, args...)
+		if code != 0 || !strings.HasSuffix(stderr, tt.wantStderr) || tt.wantStderr == "" && stderr != "" {
+			t.Errorf("snip %q: exit code %d, stderr %q; want 0 and %q", args, code, stderr, tt.wantStderr)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != contents {
+			t.Errorf("snip %q added a duplicate snippet:\n%s", args, got)
+		}
+	}
+}
+
+func TestValidateIncludeTime(t *testing.T) {
+	setClock(t, time.Date(2024, time.November, 20, 9, 15, 0, 0, time.Local))
+	for _, tt := range []struct {
+		includeTime string
+		wantErr     bool
+	}{
+		{includeTime: "15:04 | "},
+		{includeTime: ""},
+		{includeTime: "2006-01-02 15:04 | "},
+		{includeTime: "2006/01/02 | ", wantErr: true},
+		{includeTime: "01/02 15:04 | ", wantErr: true},
+		{includeTime: `2006\01\02 | `, wantErr: true},
+	} {
+		setFlag(t, "include_time", tt.includeTime)
+		err := validateFlags()
+		if tt.wantErr && exitCode(err) != exitUsage || !tt.wantErr && err != nil {
+			t.Errorf("validateFlags() with -include_time %q = %v, want usage error: %v", tt.includeTime, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// readMessage returns the snippet text given by the value of -m. Like in curl,
+// a value of the form "@path" refers to a file to read the text from, e.g. to
+// avoid shell quoting for long snippets, in which case fromFile is true. A
+// literal leading '@' is written as "@@".
+func readMessage(value string) (text string, fromFile bool, err error) {
+	if strings.HasPrefix(value, "@@") {
+		return value[1:], false, nil
+	}
+	path, ok := strings.CutPrefix(value, "@")
+	if !ok || path == "" {
+		return value, false, nil
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("read -m %s: %w", value, err)
+	}
+	return strings.TrimSpace(string(data)), true, nil
+}
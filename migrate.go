@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/saser/snip/snip"
+)
+
+// runMigrate implements the "migrate" subcommand, which rewrites the separator
+// between the timestamp and the text of the snippets in all snippet files, e.g.
+// after changing -separator.
+func runMigrate(args []string) error {
+	fs := newFlagSet("migrate")
+	notebookFlag(fs)
+	from := fs.String("from", snip.TimestampSeparator, "Separator to replace.")
+	to := fs.String("to", "", "Separator to replace -from with, e.g. \" - \".")
+	fs.BoolVar(backup, "backup", *backup, "Before rewriting a snippet file, save its current contents to a file with the same name plus \".bak\". Overrides the global -backup flag.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		return usageErrorf("migrate: -from and -to must not be empty")
+	}
+	if *from == *to {
+		return usageErrorf("migrate: -from and -to are the same")
+	}
+
+	paths, err := snippetFiles()
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	unlock, err := lockSnippets()
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	defer unlock()
+	migrated, files := 0, 0
+	for _, path := range paths {
+		existing, err := readSnippetFile(path)
+		if err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+		contents, n := migrateSeparator(existing, snip.FileName(path), *from, *to)
+		if n == 0 {
+			continue
+		}
+		if err := writeBackup(path, existing); err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+		if err := recordUndo(path, existing); err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+		if err := writeSnippetFile(path, contents); err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+		migrated += n
+		files++
+	}
+	fmt.Printf("Migrated %d snippets in %d files.\n", migrated, files)
+	return nil
+}
+
+// migrateSeparator replaces the separator from with to in the snippet lines of
+// contents, the snippet file named date (see [snip.FileName]), and returns the
+// result and the number of lines changed. Only the first occurrence in each
+// line is replaced, and only if the text before it is a timestamp in the
+// format of -include_time, since that's the separator after the timestamp.
+// Later occurrences are part of the text, as are occurrences in lines without
+// a timestamp, e.g. with -no_timestamp. Lines that have already been migrated,
+// the header, dividers and continuation lines are left alone.
+func migrateSeparator(contents []byte, date, from, to string) ([]byte, int) {
+	header, rest := splitHeader(contents)
+	var b bytes.Buffer
+	b.Write(header)
+	n := 0
+	for len(rest) != 0 {
+		line, after, found := bytes.Cut(rest, []byte{'\n'})
+		isSnippet := len(bytes.TrimSpace(line)) != 0 && !snip.IsDivider(line) && !bytes.HasPrefix(line, []byte(snip.ContinuationIndent))
+		i := bytes.Index(line, []byte(from))
+		if isSnippet && i != -1 && isTimestamp(date, string(line[:i])) {
+			b.Write(line[:i])
+			b.WriteString(to)
+			b.Write(line[i+len(from):])
+			n++
+		} else {
+			b.Write(line)
+		}
+		if found {
+			b.WriteByte('\n')
+		}
+		rest = after
+	}
+	return b.Bytes(), n
+}
+
+// isTimestamp reports whether prefix is a timestamp prefix (without the
+// separator) in the format of -include_time, in the snippet file named date.
+func isTimestamp(date, prefix string) bool {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" || timestampLayout() == "" {
+		return false
+	}
+	_, err := parseTimestamp(date, prefix)
+	return err == nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestMigrateSeparator(t *testing.T) {
+	const header = "--- Wednesday Nov 20 2024 in UTC ---\n"
+	for _, tt := range []struct {
+		name     string
+		contents string
+		want     string
+		wantN    int
+	}{
+		{
+			name:     "snippets",
+			contents: header + "09:00 | first\n10:00 | second\n",
+			want:     header + "09:00 - first\n10:00 - second\n",
+			wantN:    2,
+		},
+		{
+			name:     "separator in the text",
+			contents: header + "09:00 | a | b\n",
+			want:     header + "09:00 - a | b\n",
+			wantN:    1,
+		},
+		{
+			name:     "no timestamp",
+			contents: header + "a | b\n09:00 | c\n",
+			want:     header + "a | b\n09:00 - c\n",
+			wantN:    1,
+		},
+		{
+			name:     "continuation lines",
+			contents: header + "09:00 | title\n  a | b\n  10:00 | c\n",
+			want:     header + "09:00 - title\n  a | b\n  10:00 | c\n",
+			wantN:    1,
+		},
+		{
+			name:     "dividers",
+			contents: header + "-- 09:00 | x --\n09:00 | a\n",
+			want:     header + "-- 09:00 | x --\n09:00 - a\n",
+			wantN:    1,
+		},
+		{
+			name:     "already migrated",
+			contents: header + "09:00 - a | b\n",
+			want:     header + "09:00 - a | b\n",
+		},
+		{
+			name:     "no trailing newline",
+			contents: "09:00 | a",
+			want:     "09:00 - a",
+			wantN:    1,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := migrateSeparator([]byte(tt.contents), "2024-11-20", " | ", " - ")
+			if string(got) != tt.want || n != tt.wantN {
+				t.Errorf("migrateSeparator(%q) = %q, %d; want %q, %d", tt.contents, got, n, tt.want, tt.wantN)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import "golang.org/x/text/unicode/norm"
+
+// normalizeText applies the Unicode normalization given by -normalize to the
+// text of a snippet, so that e.g. text pasted in decomposed form (NFD) is
+// stored the same way as text typed in composed form (NFC), and searching for
+// it works either way.
+func normalizeText(text []byte) []byte {
+	switch *normalize {
+	case "nfc":
+		return norm.NFC.Bytes(text)
+	default:
+		return text
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeNFC(t *testing.T) {
+	const (
+		decomposed = "cafe\u0301"
+		composed   = "caf\u00e9"
+	)
+	for _, tt := range []struct {
+		normalize string
+		want      string
+	}{
+		{normalize: "", want: "09:15 | " + decomposed + "\n"},
+		{normalize: "nfc", want: "09:15 | " + composed + "\n"},
+	} {
+		t.Run("normalize="+tt.normalize, func(t *testing.T) {
+			now := time.Date(2024, time.November, 20, 9, 15, 0, 0, time.Local)
+			setClock(t, now)
+			setFlag(t, "dir", t.TempDir())
+			setFlag(t, "normalize", tt.normalize)
+			if _, err := writeSnippets([][]byte{[]byte(decomposed)}); err != nil {
+				t.Fatalf("writeSnippets failed: %v", err)
+			}
+			if got := readSnippets(t, now); got != tt.want {
+				t.Errorf("snippets = %+q, want %+q", got, tt.want)
+			}
+		})
+	}
+}
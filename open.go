@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// runOpen implements the "open" subcommand, which opens the snippet file for a
+// given day (today by default) in the user's editor. Unlike when adding a
+// snippet, the editor works on the real file, and its contents are left as-is.
+func runOpen(args []string) error {
+	flags := newFlagSet("open")
+	notebookFlag(flags)
+	date := flags.String("date", "", "Date to open the snippet file for, in the format YYYY-MM-DD. Defaults to today.")
+	flags.BoolVar(refresh, "refresh_header", *refresh, "Replace the header line of the snippet file, if any, with a freshly formatted one before opening it.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	t := dayOf(clock())
+	if d := *date; d != "" {
+		var err error
+		t, err = parseDateFlag("date", d)
+		if err != nil {
+			return fmt.Errorf("open snippet file: %w", err)
+		}
+	}
+	path, err := snippetPath(t)
+	if err != nil {
+		return fmt.Errorf("open snippet file: %w", err)
+	}
+	if isEncrypted(path) {
+		return fmt.Errorf("open snippet file: %s is encrypted, so it can't be opened in an editor", path)
+	}
+	if err := checkBaseDir(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), fs.FileMode(dirMode)); err != nil {
+		return fmt.Errorf("open snippet file: ensure directory exists: %w", err)
+	}
+	if *refresh {
+		if err := refreshHeader(path, t); err != nil {
+			return fmt.Errorf("open snippet file: %w", err)
+		}
+	}
+	// Place the cursor at the end of the file, if the editor supports it.
+	line := 1
+	if contents, err := os.ReadFile(path); err == nil {
+		line = lastLine(contents)
+	}
+	if err := openEditor(path, line); err != nil {
+		return fmt.Errorf("open $EDITOR on snippet file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// checkSnippetOrder checks, for -check_order and -strict_order, that a new
+// snippet timestamped at t doesn't come before the last snippet in the snippet
+// file at path with the existing contents previous, e.g. because the system
+// clock was adjusted or -at was given an earlier time. Snippets whose
+// timestamps can't be parsed are skipped, so that hand-written or old lines
+// don't get in the way.
+//
+// With -strict_order, an out of order snippet is an error; otherwise it's only
+// logged as a warning.
+func checkSnippetOrder(path string, previous []byte, t time.Time) error {
+	name := snip.FileName(path)
+	next, err := parseOrderedTimestamp(name, t.Format(timestampLayout()))
+	if err != nil {
+		return nil
+	}
+	lines := snippetLines(previous)
+	for i := len(lines) - 1; i >= 0; i-- {
+		prefix, _, ok := splitTimestamp(string(lines[i]))
+		if !ok {
+			continue
+		}
+		last, err := parseOrderedTimestamp(name, prefix)
+		if err != nil {
+			continue
+		}
+		if !next.Before(last) {
+			return nil
+		}
+		if *strictOrder {
+			return fmt.Errorf("snippet timestamped %s would come after a snippet timestamped %s in %s; check the system clock or -at", next.Format(time.DateTime), last.Format(time.DateTime), path)
+		}
+		slog.Warn("Snippet is out of order; check the system clock or -at", "file", path, "timestamp", next.Format(time.DateTime), "last", last.Format(time.DateTime))
+		return nil
+	}
+	return nil
+}
+
+// snippetsAfter returns a function for [snip.AppendOptions.InsertBefore] with
+// -insert_sorted, which reports whether a snippet line in the snippet file for
+// fileTime has a timestamp later than t. Lines whose timestamps can't be
+// parsed are never reported, so that they keep their positions.
+func snippetsAfter(fileTime, t time.Time) (func(line []byte) bool, error) {
+	path, err := snippetPath(fileTime)
+	if err != nil {
+		return nil, err
+	}
+	name := snip.FileName(path)
+	next, err := parseOrderedTimestamp(name, t.Format(timestampLayout()))
+	if err != nil {
+		// Without a comparable timestamp, add the snippet at the end.
+		return nil, nil
+	}
+	return func(line []byte) bool {
+		prefix, _, ok := splitTimestamp(string(line))
+		if !ok {
+			return false
+		}
+		ts, err := parseOrderedTimestamp(name, prefix)
+		return err == nil && ts.After(next)
+	}, nil
+}
+
+// parseOrderedTimestamp is like [parseTimestamp], but with -day_start,
+// timestamps before the start of the day are moved to the next calendar date,
+// where they belong in the order of the snippet file.
+func parseOrderedTimestamp(date, prefix string) (time.Time, error) {
+	t, err := parseTimestamp(date, prefix)
+	if err != nil {
+		return t, err
+	}
+	if beforeDayStart(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
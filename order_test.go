@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+func TestCheckSnippetOrder(t *testing.T) {
+	setFlag(t, "strict_order", "true")
+	const header = "--- Wednesday Nov 20 2024 in UTC ---\n"
+	at := time.Date(2024, time.November, 20, 10, 0, 0, 0, time.Local)
+	for _, tt := range []struct {
+		name     string
+		previous string
+		wantErr  bool
+	}{
+		{name: "empty", previous: ""},
+		{name: "in order", previous: header + "09:00 | a\n09:59 | b\n"},
+		{name: "same time", previous: header + "10:00 | a\n"},
+		{name: "out of order", previous: header + "09:00 | a\n10:30 | b\n", wantErr: true},
+		{name: "only earlier snippets are out of order", previous: header + "10:30 | a\n09:00 | b\n"},
+		{name: "unparseable last timestamp", previous: header + "10:30 | a\nsoon | b\n", wantErr: true},
+		{name: "no timestamps", previous: header + "a\nb\n"},
+		{name: "continuation lines", previous: header + "10:30 | a\n  11:00 | b\n", wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSnippetOrder("2024-11-20.txt", []byte(tt.previous), at)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkSnippetOrder(%q) = %v, want error: %v", tt.previous, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInsertSorted(t *testing.T) {
+	const existing = "--- Wednesday Nov 20 2024 in UTC ---\n09:00 | a\nnote without a timestamp\n10:00 | b\n  more\n"
+	for _, tt := range []struct {
+		name string
+		at   string
+		want string
+	}{
+		{
+			name: "middle",
+			at:   "09:30",
+			want: "--- Wednesday Nov 20 2024 in UTC ---\n09:00 | a\nnote without a timestamp\n09:30 | new\n10:00 | b\n  more\n",
+		},
+		{
+			name: "front",
+			at:   "08:00",
+			want: "--- Wednesday Nov 20 2024 in UTC ---\n08:00 | new\n09:00 | a\nnote without a timestamp\n10:00 | b\n  more\n",
+		},
+		{
+			name: "end",
+			at:   "11:00",
+			want: existing + "11:00 | new\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			setClock(t, time.Date(2024, time.November, 20, 12, 0, 0, 0, time.Local))
+			base := t.TempDir()
+			setFlag(t, "dir", base)
+			setFlag(t, "insert_sorted", "true")
+			setFlag(t, "at", tt.at)
+			path := filepath.Join(base, "2024-11-20.txt")
+			if err := os.WriteFile(path, []byte(existing), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := writeSnippets([][]byte{[]byte("new")}); err != nil {
+				t.Fatalf("writeSnippets failed: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("snippet file:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepend(t *testing.T) {
+	const header = "--- Wednesday Nov 20 2024 ---\n"
+	for _, tt := range []struct {
+		name     string
+		noFile   bool
+		existing string
+		want     string
+	}{
+		{
+			name:   "no file",
+			noFile: true,
+			want:   snip.HeaderMarker + header + "10:00 | new\n",
+		},
+		{
+			name:     "empty file",
+			existing: "",
+			want:     snip.HeaderMarker + header + "10:00 | new\n",
+		},
+		{
+			name:     "header only",
+			existing: header,
+			want:     header + "10:00 | new\n",
+		},
+		{
+			name:     "snippets",
+			existing: header + "09:00 | a\n  more\n09:30 | b\n",
+			want:     header + "10:00 | new\n09:00 | a\n  more\n09:30 | b\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			setClock(t, time.Date(2024, time.November, 20, 10, 0, 0, 0, time.Local))
+			base := t.TempDir()
+			setFlag(t, "dir", base)
+			setFlag(t, "header_format", "--- Monday Jan _2 2006 ---")
+			setFlag(t, "prepend", "true")
+			path := filepath.Join(base, "2024-11-20.txt")
+			if !tt.noFile {
+				if err := os.WriteFile(path, []byte(tt.existing), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := writeSnippets([][]byte{[]byte("new")}); err != nil {
+				t.Fatalf("writeSnippets failed: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("snippet file:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// formatSnippetText formats the (trimmed) text of a snippet for writing to a
+// snippet file. By default, newlines are replaced with spaces, so that each
+// snippet is only on one line. With -multiline, line breaks are preserved
+// instead by indenting all lines but the first with [snip.ContinuationIndent],
+// and blank lines are dropped. With -raw, only the first line is formatted,
+// and the rest is kept verbatim in a code block; see [formatRawSnippet]. With
+// -bullets, the lines after the first become list items; see
+// [formatBulletSnippet].
+func formatSnippetText(text []byte) []byte {
+	if *raw {
+		return formatRawSnippet(text)
+	}
+	if *bullets {
+		return formatBulletSnippet(text)
+	}
+	if !*multiline {
+		return bytes.ReplaceAll(text, []byte{'\n'}, []byte{' '})
+	}
+	var b bytes.Buffer
+	for i, line := range bytes.Split(text, []byte{'\n'}) {
+		line = bytes.TrimRight(line, " \t\r")
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if i != 0 {
+			b.WriteByte('\n')
+			b.WriteString(snip.ContinuationIndent)
+		}
+		b.Write(line)
+	}
+	return b.Bytes()
+}
+
+// formatRawSnippet formats a snippet for -raw: the first line is the title of
+// the snippet, and the remaining lines are indented as continuation lines in a
+// code block (see [snip.CodeFence]), preserving their leading whitespace and
+// any blank lines between them. If the body is already a code block, e.g.
+// because an existing raw snippet is being edited with -edit_last, it's not
+// fenced again.
+func formatRawSnippet(text []byte) []byte {
+	title, body, _ := bytes.Cut(text, []byte{'\n'})
+	var b bytes.Buffer
+	b.Write(bytes.TrimSpace(title))
+	// Drop blank lines between the title and the body, but not the
+	// indentation of the first line of the body.
+	lines := bytes.Split(bytes.TrimRight(body, " \t\r\n"), []byte{'\n'})
+	for len(lines) != 0 && len(bytes.TrimSpace(lines[0])) == 0 {
+		lines = lines[1:]
+	}
+	if n := len(lines); n >= 2 && isFenceLine(lines[0]) && isFenceLine(lines[n-1]) {
+		lines = lines[1 : n-1]
+	}
+	if len(lines) == 0 {
+		return b.Bytes()
+	}
+	b.WriteString("\n" + snip.ContinuationIndent + snip.CodeFence)
+	for _, line := range lines {
+		b.WriteString("\n" + snip.ContinuationIndent)
+		b.Write(bytes.TrimRight(line, " \t\r"))
+	}
+	b.WriteString("\n" + snip.ContinuationIndent + snip.CodeFence)
+	return b.Bytes()
+}
+
+// bulletMarker starts the list items of a snippet written with -bullets.
+const bulletMarker = "- "
+
+// bulletMarkers are the list markers recognized at the start of lines, and
+// replaced by bulletMarker, with -bullets.
+var bulletMarkers = []string{"- ", "* ", "+ ", "• "}
+
+// formatBulletSnippet formats a snippet for -bullets: the first line is the
+// title of the snippet, and each remaining non-blank line is a list item on a
+// continuation line, like "  - item". Any list markers the lines already have,
+// e.g. from pasted bullet points, are replaced, so that they're consistent.
+func formatBulletSnippet(text []byte) []byte {
+	lines := bytes.Split(text, []byte{'\n'})
+	var b bytes.Buffer
+	b.Write(bytes.TrimSpace(lines[0]))
+	for _, line := range lines[1:] {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		for _, marker := range bulletMarkers {
+			if rest, ok := bytes.CutPrefix(line, []byte(marker)); ok {
+				line = bytes.TrimSpace(rest)
+				break
+			}
+		}
+		b.WriteString("\n" + snip.ContinuationIndent + bulletMarker)
+		b.Write(line)
+	}
+	return b.Bytes()
+}
+
+// isFenceLine reports whether line only consists of [snip.CodeFence].
+func isFenceLine(line []byte) bool {
+	return string(bytes.TrimSpace(line)) == snip.CodeFence
+}
+
+// unindentContinuations removes the indentation from the continuation lines of
+// a snippet, i.e. the reverse of what [formatSnippetText] does with -multiline.
+func unindentContinuations(s string) string {
+	return strings.ReplaceAll(s, "\n"+snip.ContinuationIndent, "\n")
+}
+
+// indentContinuations indents all lines of a snippet but the first with
+// [snip.ContinuationIndent], i.e. the reverse of [unindentContinuations].
+func indentContinuations(text []byte) []byte {
+	return bytes.ReplaceAll(text, []byte{'\n'}, []byte("\n"+snip.ContinuationIndent))
+}
+
+// parsedSnippet is a snippet line parsed back into its components.
+type parsedSnippet struct {
+	// Date is the name of the snippet file the snippet was read from, e.g.
+	// "2024-01-15".
+	Date string `json:"date"`
+	// Time is the timestamp of the snippet. If the timestamp prefix could be
+	// parsed, it's formatted as RFC 3339; otherwise it's the raw prefix, which
+	// is empty if the line had no prefix.
+	Time string `json:"time"`
+	// Text is the snippet text, excluding the timestamp prefix. Continuation
+	// lines are separated by newlines, without their indentation.
+	Text string `json:"text"`
+	// Tags are the tags in Text, without the leading '#'.
+	Tags []string `json:"tags"`
+	// Author is the author of the snippet (see -attribute), without the
+	// leading '@', or empty if it has none.
+	Author string `json:"author"`
+	// Priority is the priority of the snippet (see -priority), e.g. "high",
+	// or empty if it has none.
+	Priority string `json:"priority"`
+
+	// prefix is the raw timestamp prefix, without the separator.
+	prefix string
+}
+
+// timestampLayout returns the layout of the timestamp prefix, as given by
+// -include_time, without the trailing separator.
+func timestampLayout() string {
+	layout := strings.TrimSpace(*includeTime)
+	for _, sep := range timestampSeparators() {
+		if sep := strings.TrimSpace(sep); sep != "" {
+			layout = strings.TrimSuffix(layout, sep)
+		}
+	}
+	return strings.TrimSpace(layout)
+}
+
+// timestampPrefix formats the timestamp prefix of a snippet written at t,
+// including the trailing separator. If -include_time ends with a separator
+// (like the default "15:04 | "), it's replaced with -separator. Otherwise
+// -include_time is used as is.
+func timestampPrefix(t time.Time) string {
+	layout := timestampLayout()
+	if layout == strings.TrimSpace(*includeTime) {
+		return t.Format(*includeTime)
+	}
+	return t.Format(layout) + *separator
+}
+
+// timestampSeparators returns the separators recognized between the timestamp
+// and the text of a snippet: -separator and the default separator, so that
+// snippets written before changing -separator can still be read.
+func timestampSeparators() []string {
+	if *separator == snip.TimestampSeparator {
+		return []string{snip.TimestampSeparator}
+	}
+	return []string{*separator, snip.TimestampSeparator}
+}
+
+// splitTimestamp splits a snippet line into its timestamp prefix and the
+// snippet text, recognizing any of [timestampSeparators]. If the line has no
+// timestamp prefix, ok is false.
+func splitTimestamp(line string) (prefix, text string, ok bool) {
+	return snip.SplitTimestamp(line, timestampSeparators()...)
+}
+
+// parseSnippetLine parses a snippet (as returned by [snippetLines]) from the
+// snippet file named date (see [snip.FileName]).
+func parseSnippetLine(date, line string) parsedSnippet {
+	s := parsedSnippet{
+		Date: date,
+		Text: line,
+	}
+	if prefix, text, ok := splitTimestamp(line); ok {
+		s.prefix = prefix
+		s.Time = prefix
+		s.Text = text
+		if t, err := parseTimestamp(date, prefix); err == nil {
+			s.Time = t.Format(time.RFC3339)
+		}
+	}
+	s.Text = unindentContinuations(s.Text)
+	// Always use a non-nil slice, so that the tags are encoded as an empty
+	// JSON array rather than null.
+	s.Tags = append([]string{}, extractTags(s.Text)...)
+	s.Author = extractAuthor(s.Text)
+	s.Priority = extractPriority(s.Text)
+	return s
+}
+
+// parseTimestamp parses the timestamp prefix of a snippet line from the snippet
+// file named date.
+func parseTimestamp(date, prefix string) (time.Time, error) {
+	layout := timestampLayout()
+	// Timestamps usually only contain the time of day, so first try to
+	// combine them with the date of the snippet file.
+	if t, err := time.ParseInLocation(time.DateOnly+" "+layout, date+" "+prefix, time.Local); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(layout, prefix, time.Local)
+}
@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+func TestFormatRawSnippet(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "title only",
+			text: "title",
+			want: "title",
+		},
+		{
+			name: "blank lines and leading whitespace",
+			text: "run it\n\nfunc main() {\n\n\tfmt.Println(\"hi\")  \n}\n\n",
+			want: "run it\n  ```\n  func main() {\n  \n  \tfmt.Println(\"hi\")\n  }\n  ```",
+		},
+		{
+			name: "indented first line",
+			text: "trace\n    at main.go:12\n    at main.go:3",
+			want: "trace\n  ```\n      at main.go:12\n      at main.go:3\n  ```",
+		},
+		{
+			name: "already fenced",
+			text: "cmd\n```\nls -l\n```",
+			want: "cmd\n  ```\n  ls -l\n  ```",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(formatRawSnippet([]byte(tt.text))); got != tt.want {
+				t.Errorf("formatRawSnippet(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatBulletSnippet(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "title only",
+			text: "title",
+			want: "title",
+		},
+		{
+			name: "empty interior lines are dropped",
+			text: "standup\nreviewed PRs\n\n   \nfixed the build\n",
+			want: "standup\n  - reviewed PRs\n  - fixed the build",
+		},
+		{
+			name: "existing markers are replaced",
+			text: "  todo  \n* one\n+ two\n• three\n- four",
+			want: "todo\n  - one\n  - two\n  - three\n  - four",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(formatBulletSnippet([]byte(tt.text))); got != tt.want {
+				t.Errorf("formatBulletSnippet(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// runPath implements the "path" subcommand, which prints the absolute path of
+// the snippet file for a given day (today by default), so that scripts don't
+// have to reimplement how snip resolves it.
+func runPath(args []string) error {
+	fs := newFlagSet("path")
+	notebookFlag(fs)
+	date := fs.String("date", "", "Date to print the snippet file path for, in the format YYYY-MM-DD. Defaults to today.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	t := dayOf(clock())
+	if d := *date; d != "" {
+		var err error
+		t, err = parseDateFlag("date", d)
+		if err != nil {
+			return fmt.Errorf("path: %w", err)
+		}
+	}
+	path, err := snippetPath(t)
+	if err != nil {
+		return fmt.Errorf("path: %w", err)
+	}
+	// The base directory can be relative, e.g. if -dir is.
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("path: %w", err)
+	}
+	fmt.Println(abs)
+	return nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+)
+
+// permFlag is a flag.Value for file permissions given in octal, like "0644".
+type permFlag fs.FileMode
+
+func (p *permFlag) String() string {
+	return fmt.Sprintf("%#o", fs.FileMode(*p))
+}
+
+func (p *permFlag) Set(value string) error {
+	n, err := strconv.ParseUint(value, 8, 32)
+	if err != nil {
+		return fmt.Errorf("invalid mode %q: must be an octal number like 0644", value)
+	}
+	if m := fs.FileMode(n); m&^fs.ModePerm != 0 {
+		return fmt.Errorf("invalid mode %q: must only contain permission bits (at most 0777)", value)
+	}
+	*p = permFlag(n)
+	return nil
+}
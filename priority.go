@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// priorities are the priorities a snippet can be marked with (see -priority),
+// from highest to lowest, with the marker the snippet text starts with. The
+// markers are fixed, so that snippets can be filtered by them reliably.
+var priorities = []struct {
+	name, marker string
+}{
+	{"high", "[!]"},
+	{"med", "[~]"},
+	{"low", "[.]"},
+}
+
+// priorityMarker returns the marker for the priority with the given name.
+func priorityMarker(name string) (marker string, ok bool) {
+	for _, p := range priorities {
+		if p.name == name {
+			return p.marker, true
+		}
+	}
+	return "", false
+}
+
+// validatePriority checks that the value of the priority flag with the given
+// name is a valid priority, or empty.
+func validatePriority(flagName, name string) error {
+	if _, ok := priorityMarker(name); name != "" && !ok {
+		return usageErrorf("invalid -%s %q: must be \"high\", \"med\", or \"low\"", flagName, name)
+	}
+	return nil
+}
+
+// prependPriority prepends the marker of the priority with the given name to
+// text, like "[!] text".
+func prependPriority(text []byte, name string) []byte {
+	marker, _ := priorityMarker(name)
+	return fmt.Appendf(nil, "%s %s", marker, text)
+}
+
+// extractPriority returns the name of the priority of a snippet with the given
+// text (without the timestamp prefix), i.e. the priority whose marker the text
+// starts with, or the empty string if there is none.
+func extractPriority(text string) string {
+	for _, p := range priorities {
+		if strings.HasPrefix(text, p.marker+" ") {
+			return p.name
+		}
+	}
+	return ""
+}
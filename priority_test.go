@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriorityRoundTrip(t *testing.T) {
+	for _, name := range []string{"high", "med", "low"} {
+		text := prependPriority([]byte("fix the build"), name)
+		if got := extractPriority(string(text)); got != name {
+			t.Errorf("extractPriority(%q) = %q, want %q", text, got, name)
+		}
+	}
+	for _, text := range []string{"fix the build", "[!]fix the build", "fix [!] the build"} {
+		if got := extractPriority(text); got != "" {
+			t.Errorf("extractPriority(%q) = %q, want no priority", text, got)
+		}
+	}
+}
+
+func TestListPriority(t *testing.T) {
+	base := t.TempDir()
+	now := time.Date(2024, time.November, 20, 9, 0, 0, 0, time.Local)
+	setClock(t, now)
+	setFlag(t, "dir", base)
+	for _, p := range []struct{ priority, text string }{
+		{"high", "outage"},
+		{"", "lunch"},
+		{"low", "tidy up"},
+		{"high", "rollback"},
+	} {
+		setFlag(t, "priority", p.priority)
+		if _, err := writeSnippets([][]byte{[]byte(p.text)}); err != nil {
+			t.Fatalf("writeSnippets failed: %v", err)
+		}
+	}
+	stdout, stderr, code := runSnip(t, "-dir", base, "list", "-date", "2024-11-20", "-priority", "high", "-no_header")
+	if code != 0 {
+		t.Fatalf("snip list -priority failed with exit code %d: %s", code, stderr)
+	}
+	if want := "09:00 | [!] outage\n09:00 | [!] rollback\n"; stdout != want {
+		t.Errorf("snip list -priority high printed %q, want %q", stdout, want)
+	}
+}
+
+func TestValidatePriority(t *testing.T) {
+	for _, name := range []string{"", "high", "med", "low"} {
+		if err := validatePriority("priority", name); err != nil {
+			t.Errorf("validatePriority(%q) = %v, want nil", name, err)
+		}
+	}
+	if err := validatePriority("priority", "urgent"); exitCode(err) != exitUsage {
+		t.Errorf("validatePriority(%q) = %v, want a usage error", "urgent", err)
+	}
+}
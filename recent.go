@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// runRecent implements the "recent" subcommand, which prints the most recent
+// days with snippets, newest first, with the number of snippets on each.
+func runRecent(args []string) error {
+	fs := newFlagSet("recent")
+	notebookFlag(fs)
+	n := fs.Int("n", 7, "Number of days to print.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *n < 1 {
+		return usageErrorf("recent: invalid -n %d: must be at least 1", *n)
+	}
+
+	paths, err := snippetFiles()
+	if err != nil {
+		return fmt.Errorf("recent: %w", err)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	// Walk backwards from the newest snippet file, skipping files without any
+	// snippets (e.g. only a header), regardless of any gaps between the days.
+	days := 0
+	for i := len(paths) - 1; i >= 0 && days < *n; i-- {
+		path := paths[i]
+		date, ok := snip.FileDate(path)
+		if !ok {
+			continue
+		}
+		contents, err := readSnippetFile(path)
+		if err != nil {
+			return fmt.Errorf("recent: %w", err)
+		}
+		count := len(snippetLines(contents))
+		if count == 0 {
+			continue
+		}
+		unit := "snippets"
+		if count == 1 {
+			unit = "snippet"
+		}
+		fmt.Fprintf(w, "%s %-9s %d %s\n", date.Format(time.DateOnly), date.Weekday(), count, unit)
+		days++
+	}
+	if days == 0 {
+		return fmt.Errorf("recent: no snippets found")
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("recent: %w", err)
+	}
+	return nil
+}
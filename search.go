@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/saser/snip/snip"
+)
+
+// runSearch implements the "search" subcommand, which prints all snippets
+// matching a pattern across all snippet files.
+func runSearch(args []string) error {
+	fs := newFlagSet("search")
+	notebookFlag(fs)
+	colorFlag(fs)
+	ignoreCase := fs.Bool("i", true, "Match case-insensitively.")
+	useRegexp := fs.Bool("regexp", false, "Interpret the pattern as a Go regular expression; see https://pkg.go.dev/regexp/syntax.")
+	print0 := fs.Bool("print0", false, "Terminate each match with a NUL byte instead of a newline, e.g. for xargs -0. Implies -color=never.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("search: expected exactly one pattern, got %d arguments", fs.NArg())
+	}
+	re, err := compilePattern(fs.Arg(0), *ignoreCase, *useRegexp)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	color := useColor() && !*print0
+	term := "\n"
+	if *print0 {
+		term = "\x00"
+	}
+
+	paths, err := snippetFiles()
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	for _, path := range paths {
+		contents, err := readSnippetFile(path)
+		if err != nil {
+			return fmt.Errorf("search: %w", err)
+		}
+		date := snip.FileName(path)
+		for _, line := range snippetLines(contents) {
+			if !re.Match(line) {
+				continue
+			}
+			if color {
+				fmt.Fprintf(w, "%s %s%s", colorize(date, ansiBold), colorSnippet(string(line), re), term)
+				continue
+			}
+			fmt.Fprintf(w, "%s %s%s", date, line, term)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	return nil
+}
+
+// newMatcher returns a function reporting whether a line matches pattern,
+// which is a Go regular expression if useRegexp is set, and a substring
+// otherwise.
+func newMatcher(pattern string, ignoreCase, useRegexp bool) (func(line string) bool, error) {
+	re, err := compilePattern(pattern, ignoreCase, useRegexp)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+// compilePattern compiles pattern into a regular expression: as is if
+// useRegexp is set, and otherwise matching it as a substring.
+func compilePattern(pattern string, ignoreCase, useRegexp bool) (*regexp.Regexp, error) {
+	if !useRegexp {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearchPrint0(t *testing.T) {
+	base := t.TempDir()
+	for name, contents := range map[string]string{
+		"2024-11-19.txt": "09:00 | deploy to prod\n10:00 | lunch\n",
+		"2024-11-20.txt": "09:00 | another deploy\n",
+	} {
+		if err := os.WriteFile(filepath.Join(base, name), []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stdout, stderr, code := runSnip(t, "-dir", base, "-color", "always", "search", "-print0", "deploy")
+	if code != 0 {
+		t.Fatalf("snip search failed with exit code %d: %s", code, stderr)
+	}
+	if want := "2024-11-19 09:00 | deploy to prod\x002024-11-20 09:00 | another deploy\x00"; stdout != want {
+		t.Errorf("snip search -print0 printed %q, want %q", stdout, want)
+	}
+}
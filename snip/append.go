@@ -0,0 +1,363 @@
+package snip
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AssembleOptions configures [Assemble].
+type AssembleOptions struct {
+	// Header is the header line (without a trailing newline) to add at the
+	// top of the snippet file if it doesn't already start with a header. If
+	// empty, no header is added.
+	Header string
+	// HeaderPrefix is used to recognize an existing header. Defaults to
+	// DefaultHeaderPrefix.
+	HeaderPrefix string
+	// Divider is a divider line (see [FormatDivider]) to add before the
+	// snippet line, unless the last divider in the snippet file is identical,
+	// i.e. the previous snippet is already under it. If empty, no divider is
+	// added.
+	Divider string
+}
+
+// Assemble returns the new contents of a snippet file with the existing
+// contents existing (nil if the file doesn't exist) after adding the snippet
+// lines at the bottom. The lines must not contain trailing newlines.
+//
+// The assembled contents include:
+//   - The header, if opts.Header is set and the file doesn't already start with
+//     a header. An existing header is never removed.
+//   - Any existing snippet lines.
+//   - The divider, if opts.Divider is set and differs from the last divider.
+//   - The new snippet lines.
+func Assemble(existing []byte, lines [][]byte, opts AssembleOptions) []byte {
+	var assembled bytes.Buffer
+
+	if opts.Header != "" && !HasHeader(existing, cmp.Or(opts.HeaderPrefix, DefaultHeaderPrefix)) {
+		assembled.WriteString(MarkHeader(opts.Header) + "\n")
+	}
+
+	// Include the existing snippets, if any. If the file ends with blank lines
+	// (e.g. because it was edited by hand), drop them so that the new snippet
+	// isn't separated from the existing ones by a gap.
+	existing = trimTrailingBlankLines(existing)
+	assembled.Write(existing)
+	// In case the existing snippets didn't contain a newline, write one out, so
+	// that the new snippet is guaranteed to be on a new line. Only do this if
+	// there are already any existing snippets -- there should be no _leading_
+	// newlines in case the existing snippets are empty (e.g. because this is
+	// the first snippet of the day).
+	if n := len(existing); n != 0 && existing[n-1] != '\n' {
+		assembled.WriteByte('\n')
+	}
+
+	if d := opts.Divider; d != "" && string(lastDivider(existing)) != d {
+		assembled.WriteString(d + "\n")
+	}
+
+	// Finally, add the new snippets at the end, with trailing newlines.
+	for _, line := range lines {
+		assembled.Write(line)
+		assembled.WriteByte('\n')
+	}
+	return assembled.Bytes()
+}
+
+// trimTrailingBlankLines removes any blank lines at the end of contents, leaving
+// at most the newline terminating the last non-blank line.
+func trimTrailingBlankLines(contents []byte) []byte {
+	end := len(bytes.TrimRight(contents, " \t\r\n"))
+	if end == 0 {
+		return nil
+	}
+	if i := bytes.IndexByte(contents[end:], '\n'); i != -1 {
+		return contents[:end+i+1]
+	}
+	return contents
+}
+
+// Default permissions for snippet files and directories written by [Appender].
+const (
+	DefaultFileMode fs.FileMode = 0o600
+	DefaultDirMode  fs.FileMode = 0o755
+)
+
+// Appender appends snippets to the snippet files in a directory.
+type Appender struct {
+	// Dir is the directory containing the snippet files. It's created if it
+	// doesn't exist.
+	Dir string
+	// LockDir is the directory containing the lock file taken while appending
+	// (see [Lock]). Defaults to Dir.
+	LockDir string
+	// Granularity determines which snippet file to append to. Defaults to Day.
+	Granularity Granularity
+	// Layout determines where in Dir the snippet file is. Defaults to Flat.
+	Layout Layout
+	// Header formats the header line (without a trailing newline) for the
+	// snippet file containing snippets timestamped at t. It's only called if
+	// the snippet file doesn't already start with a header. If nil, no header
+	// is added.
+	Header func(t time.Time) string
+	// HeaderPrefix is used to recognize the header of existing snippet files.
+	// Defaults to DefaultHeaderPrefix.
+	HeaderPrefix string
+	// Separators are used to recognize the timestamp prefix of snippet lines
+	// (see [SplitTimestamp]). Defaults to TimestampSeparator.
+	Separators []string
+	// FileMode is the permissions for written snippet files. Defaults to
+	// DefaultFileMode.
+	FileMode fs.FileMode
+	// DirMode is the permissions for created directories. Defaults to
+	// DefaultDirMode.
+	DirMode fs.FileMode
+	// OnWrite, if set, is called with the path and the previous contents of
+	// the snippet file (nil if it didn't exist) right before it's written,
+	// while the lock is held. If it returns an error, the file isn't written.
+	OnWrite func(path string, previous []byte) error
+	// Cipher, if set, is used to store the snippet files encrypted, with
+	// EncryptedExt added to their names. The existing contents are decrypted
+	// after reading them, and the assembled contents are encrypted before
+	// writing them; AppendResult.Contents is the plaintext.
+	Cipher Cipher
+	// NonAtomicFallback makes writes fall back to overwriting the snippet
+	// file in place if the filesystem doesn't support atomic writes; see
+	// [WriteFile].
+	NonAtomicFallback bool
+	// IORetries is the number of times to retry reading or writing the
+	// snippet file if it fails with a transient error; see [Retry].
+	IORetries int
+}
+
+// AppendOptions configures [Appender.Append].
+type AppendOptions struct {
+	// Lines are the snippet lines to append, including any timestamp prefix
+	// but without trailing newlines. They're all appended in a single write.
+	Lines [][]byte
+	// Time determines which snippet file to append to, and the date in its
+	// header.
+	Time time.Time
+	// Divider is a divider line to add before the snippet lines if needed;
+	// see AssembleOptions.Divider.
+	Divider string
+	// Dedup skips appending each line whose text (ignoring the timestamp
+	// prefix) is identical to that of the snippet before it, i.e. the last
+	// snippet in the file or the previous line.
+	Dedup bool
+	// Continue adds the text of the lines (without their timestamp prefixes)
+	// to the end of the last snippet in the file, separated by
+	// ContinueSeparator, instead of appending them as new lines. If the file
+	// doesn't contain any snippets, the lines are appended as usual.
+	Continue bool
+	// InsertBefore, if set, is called with the first line of each existing
+	// snippet in the file, in order, and the lines are inserted before the
+	// first snippet for which it returns true, e.g. to keep the snippets in
+	// chronological order. If it doesn't return true for any snippet, the
+	// lines are appended as usual. No divider is added when inserting.
+	InsertBefore func(line []byte) bool
+	// DryRun assembles the snippet file without writing it, or creating any
+	// directories or lock files.
+	DryRun bool
+}
+
+// AppendResult is the result of [Appender.Append].
+type AppendResult struct {
+	// Path is the path of the snippet file.
+	Path string
+	// Contents are the assembled contents of the snippet file. If no lines
+	// were appended, it's nil.
+	Contents []byte
+	// Appended is the number of lines appended. It's only set once the
+	// snippet file has been written (or assembled, in a dry run).
+	Appended int
+	// Duplicates is the number of lines skipped because of
+	// AppendOptions.Dedup.
+	Duplicates int
+}
+
+// Append appends snippet lines to the end of their snippet file, adding a
+// header first if needed.
+//
+// To prevent 0-byte or half-written snippet files, the result is written to a
+// temporary file and then atomically moved into place using the
+// github.com/google/renameio package. This might seem excessive for something
+// that's just personal notes stored locally, but for the author of this
+// program these snippets are very valuable, so it's worth being a bit
+// paranoid.
+func (a *Appender) Append(ctx context.Context, opts AppendOptions) (AppendResult, error) {
+	path, err := SnippetPath(a.Dir, cmp.Or(a.Granularity, Day), opts.Time)
+	if err != nil {
+		return AppendResult{}, fmt.Errorf("write snippet out to file: %w", err)
+	}
+	path = LayoutPath(path, a.Layout)
+	if a.Cipher != nil {
+		path += EncryptedExt
+	}
+	res := AppendResult{Path: path}
+	// In a dry run, nothing should be created on disk, including directories
+	// and the lock file.
+	if !opts.DryRun {
+		if err := os.MkdirAll(filepath.Dir(path), cmp.Or(a.DirMode, DefaultDirMode)); err != nil {
+			return res, fmt.Errorf("write snippet out to file: ensure directory exists: %w", err)
+		}
+		// Hold the lock from reading the existing snippets until the assembled
+		// file has been written.
+		unlock, err := Lock(ctx, cmp.Or(a.LockDir, a.Dir))
+		if err != nil {
+			return res, fmt.Errorf("write snippet out to file: %w", err)
+		}
+		defer unlock()
+	}
+
+	// If the snippet file already exists, read it back in. We might need to add
+	// the header, and we need to include any existing snippet lines.
+	var existing []byte
+	err = Retry(a.IORetries, func() error {
+		var err error
+		existing, err = os.ReadFile(path)
+		return err
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		// The file doesn't exist, which is fine, just initialize with empty
+		// contents.
+		existing = nil
+	} else if err != nil {
+		// Some other error occurred and we don't know how to handle it.
+		return res, fmt.Errorf("write snippet out to file: read existing snippets: %w", err)
+	} else if a.Cipher != nil {
+		existing, err = a.Cipher.Decrypt(existing)
+		if err != nil {
+			return res, fmt.Errorf("write snippet out to file: read existing snippets: %w", err)
+		}
+	}
+
+	prefix := cmp.Or(a.HeaderPrefix, DefaultHeaderPrefix)
+	lines := opts.Lines
+	if opts.Dedup {
+		var last string
+		_, rest := SplitHeader(existing, prefix)
+		if start, end, ok := LastSnippet(rest); ok {
+			last = a.snippetText(rest[start:end])
+		}
+		lines = nil
+		for _, line := range opts.Lines {
+			if text := a.snippetText(line); text != last {
+				lines = append(lines, line)
+				last = text
+			}
+		}
+		res.Duplicates = len(opts.Lines) - len(lines)
+	}
+	if len(lines) == 0 {
+		return res, nil
+	}
+
+	if opts.Continue {
+		header, rest := SplitHeader(existing, prefix)
+		if _, end, ok := LastSnippet(rest); ok {
+			end += len(header)
+			var b bytes.Buffer
+			b.Write(existing[:end])
+			for _, line := range lines {
+				b.WriteString(ContinueSeparator + a.snippetText(line))
+			}
+			b.Write(existing[end:])
+			res.Contents = b.Bytes()
+			return a.write(res, len(lines), existing, opts.DryRun)
+		}
+	}
+
+	if opts.InsertBefore != nil {
+		header, rest := SplitHeader(existing, prefix)
+		if i, ok := insertionPoint(rest, opts.InsertBefore); ok {
+			var b bytes.Buffer
+			if a.Header != nil && !HasHeader(existing, prefix) {
+				b.WriteString(MarkHeader(a.Header(opts.Time)) + "\n")
+			}
+			b.Write(header)
+			b.Write(rest[:i])
+			for _, line := range lines {
+				b.Write(line)
+				b.WriteByte('\n')
+			}
+			b.Write(rest[i:])
+			res.Contents = b.Bytes()
+			return a.write(res, len(lines), existing, opts.DryRun)
+		}
+	}
+
+	aopts := AssembleOptions{
+		HeaderPrefix: prefix,
+		Divider:      opts.Divider,
+	}
+	if a.Header != nil && !HasHeader(existing, prefix) {
+		aopts.Header = a.Header(opts.Time)
+	}
+	res.Contents = Assemble(existing, lines, aopts)
+	return a.write(res, len(lines), existing, opts.DryRun)
+}
+
+// insertionPoint returns the offset in snippets (without the header) of the
+// start of the first snippet whose first line before reports true for. If
+// there is none, ok is false.
+func insertionPoint(snippets []byte, before func(line []byte) bool) (offset int, ok bool) {
+	for offset < len(snippets) {
+		line, _, _ := bytes.Cut(snippets[offset:], []byte{'\n'})
+		isSnippet := len(bytes.TrimSpace(line)) != 0 && !IsDivider(line) && !bytes.HasPrefix(line, []byte(ContinuationIndent))
+		if isSnippet && before(line) {
+			return offset, true
+		}
+		offset += len(line) + 1
+	}
+	return 0, false
+}
+
+// write atomically writes the assembled contents in res to the snippet file,
+// replacing the existing contents, unless dryRun is set. Only if that succeeds
+// is res.Appended set to appended.
+func (a *Appender) write(res AppendResult, appended int, existing []byte, dryRun bool) (AppendResult, error) {
+	if dryRun {
+		res.Appended = appended
+		return res, nil
+	}
+	if a.OnWrite != nil {
+		if err := a.OnWrite(res.Path, existing); err != nil {
+			return res, fmt.Errorf("write snippet out to file: %w", err)
+		}
+	}
+	data := res.Contents
+	if a.Cipher != nil {
+		var err error
+		data, err = a.Cipher.Encrypt(data)
+		if err != nil {
+			return res, fmt.Errorf("write snippet out to file: %w", err)
+		}
+	}
+	err := Retry(a.IORetries, func() error {
+		return WriteFile(res.Path, data, cmp.Or(a.FileMode, DefaultFileMode), a.NonAtomicFallback)
+	})
+	if err != nil {
+		return res, fmt.Errorf("write snippet out to file: %w", err)
+	}
+	res.Appended = appended
+	return res, nil
+}
+
+// snippetText returns the text of a snippet line, without the timestamp prefix
+// and surrounding whitespace.
+func (a *Appender) snippetText(line []byte) string {
+	s := string(line)
+	if _, text, ok := SplitTimestamp(s, a.Separators...); ok {
+		s = text
+	}
+	return strings.TrimSpace(s)
+}
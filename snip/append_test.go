@@ -0,0 +1,159 @@
+package snip
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAssemble(t *testing.T) {
+	const header = "--- Wednesday Nov 20 2024 in UTC ---"
+	lines := [][]byte{[]byte("10:00 | new")}
+	for _, tt := range []struct {
+		name     string
+		existing string
+		opts     AssembleOptions
+		want     string
+	}{
+		{
+			name: "new file",
+			opts: AssembleOptions{Header: header},
+			want: HeaderMarker + header + "\n10:00 | new\n",
+		},
+		{
+			name:     "existing snippets",
+			existing: HeaderMarker + header + "\n09:00 | old\n",
+			opts:     AssembleOptions{Header: header},
+			want:     HeaderMarker + header + "\n09:00 | old\n10:00 | new\n",
+		},
+		{
+			name:     "no trailing newline",
+			existing: "09:00 | old",
+			want:     "09:00 | old\n10:00 | new\n",
+		},
+		{
+			name:     "trailing blank lines",
+			existing: "09:00 | old\n\n\n",
+			want:     "09:00 | old\n10:00 | new\n",
+		},
+		{
+			name:     "trailing whitespace lines",
+			existing: "09:00 | old\n  \n\t\r\n",
+			want:     "09:00 | old\n10:00 | new\n",
+		},
+		{
+			name:     "only blank lines",
+			existing: "\n\n\n",
+			want:     "10:00 | new\n",
+		},
+		{
+			name:     "divider",
+			existing: "09:00 | old\n",
+			opts:     AssembleOptions{Divider: "-- 10:00 --"},
+			want:     "09:00 | old\n-- 10:00 --\n10:00 | new\n",
+		},
+		{
+			name:     "same divider",
+			existing: "-- 10:00 --\n10:00 | old\n",
+			opts:     AssembleOptions{Divider: "-- 10:00 --"},
+			want:     "-- 10:00 --\n10:00 | old\n10:00 | new\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var existing []byte
+			if tt.existing != "" {
+				existing = []byte(tt.existing)
+			}
+			if got := string(Assemble(existing, lines, tt.opts)); got != tt.want {
+				t.Errorf("Assemble(%q) = %q, want %q", tt.existing, got, tt.want)
+			}
+		})
+	}
+}
+
+// appendTo appends lines to a snippet file for 2024-11-20 with the given
+// existing contents (none if empty) in a dry run, and returns the assembled
+// contents.
+func appendTo(t *testing.T, existing string, opts AppendOptions) string {
+	t.Helper()
+	dir := t.TempDir()
+	if existing != "" {
+		if err := os.WriteFile(filepath.Join(dir, "2024-11-20.txt"), []byte(existing), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := &Appender{
+		Dir:    dir,
+		Header: func(t time.Time) string { return "--- Wednesday Nov 20 2024 ---" },
+	}
+	opts.Time = time.Date(2024, time.November, 20, 10, 0, 0, 0, time.Local)
+	opts.DryRun = true
+	res, err := a.Append(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	return string(res.Contents)
+}
+
+func TestAppendContinue(t *testing.T) {
+	const header = HeaderMarker + "--- Wednesday Nov 20 2024 ---\n"
+	for _, tt := range []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{
+			name:     "previous snippet",
+			existing: header + "09:00 | first\n",
+			want:     header + "09:00 | first; second\n",
+		},
+		{
+			name:     "previous multiline snippet",
+			existing: header + "08:00 | zeroth\n09:00 | first\n  more\n",
+			want:     header + "08:00 | zeroth\n09:00 | first\n  more; second\n",
+		},
+		{
+			name:     "header only",
+			existing: header,
+			want:     header + "10:00 | second\n",
+		},
+		{
+			name: "no file",
+			want: header + "10:00 | second\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := appendTo(t, tt.existing, AppendOptions{
+				Lines:    [][]byte{[]byte("10:00 | second")},
+				Continue: true,
+			})
+			if got != tt.want {
+				t.Errorf("Append with Continue = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendRejected(t *testing.T) {
+	dir := t.TempDir()
+	a := &Appender{
+		Dir:     dir,
+		OnWrite: func(string, []byte) error { return errors.New("rejected") },
+	}
+	res, err := a.Append(context.Background(), AppendOptions{
+		Lines: [][]byte{[]byte("10:00 | new")},
+		Time:  time.Date(2024, time.November, 20, 10, 0, 0, 0, time.UTC),
+	})
+	if err == nil {
+		t.Fatal("Append() succeeded, want the error from OnWrite")
+	}
+	if res.Appended != 0 {
+		t.Errorf("Append() reported %d lines appended, want 0", res.Appended)
+	}
+	if _, err := os.Stat(res.Path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("%s was written (stat error: %v)", res.Path, err)
+	}
+}
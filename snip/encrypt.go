@@ -0,0 +1,64 @@
+package snip
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// EncryptedExt is added to the names of encrypted snippet files, e.g.
+// "2024-01-15.txt.age".
+const EncryptedExt = ".age"
+
+// A Cipher encrypts and decrypts the contents of snippet files, so that they
+// can be stored encrypted at rest.
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// AgeCipher is a [Cipher] using the age command-line tool
+// (https://age-encryption.org), which must be installed. The encrypted output
+// is ASCII armored, so that it can be stored as text.
+type AgeCipher struct {
+	// RecipientsFile is the path of a file with the recipients to encrypt
+	// to, one per line, as for "age -R".
+	RecipientsFile string
+	// IdentityFile is the path of a file with the identity to decrypt with,
+	// as for "age -i".
+	IdentityFile string
+}
+
+func (c AgeCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	out, err := runAge(plaintext, "--encrypt", "--armor", "--recipients-file", c.RecipientsFile)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: %w", err)
+	}
+	return out, nil
+}
+
+func (c AgeCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	out, err := runAge(ciphertext, "--decrypt", "--identity", c.IdentityFile)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return out, nil
+}
+
+// runAge runs age with the given arguments and input, and returns its output.
+// If it fails, the error includes what age printed to stderr.
+func runAge(input []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("age", args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("run age: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("run age: %w", err)
+	}
+	return out, nil
+}
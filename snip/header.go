@@ -0,0 +1,148 @@
+package snip
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHeaderFormat is the default layout of the header line, as used by
+	// [FormatHeader].
+	DefaultHeaderFormat = "--- Monday Jan _2 2006 in %tz ---"
+	// DefaultHeaderPrefix is the default prefix used to recognize header lines.
+	DefaultHeaderPrefix = "---"
+)
+
+// HeaderMarker is written at the start of header lines, to tell them apart
+// from snippets that happen to start with the header prefix, such as a
+// Markdown rule "---" recorded without a timestamp. It's the zero-width
+// U+2060 WORD JOINER, so it doesn't show up when reading the file.
+const HeaderMarker = "\u2060"
+
+// FrontMatterDelimiter is the line delimiting a YAML front matter header (see
+// [FormatFrontMatter]).
+const FrontMatterDelimiter = "---"
+
+// FormatHeader formats the header line (without a trailing newline) for the
+// snippet file containing snippets timestamped at t. The layout is as for
+// [time.Time.Format], except that the placeholder %tz is replaced with
+// timezone.
+func FormatHeader(layout string, t time.Time, timezone string) string {
+	// Use the same location as SnippetPath, so that the date in the header is
+	// guaranteed to agree with the date in the name of the snippet file, even
+	// if t is in another location (e.g. UTC) and it's a different date there.
+	t = t.Local()
+	// Format the time before substituting the timezone, so that the timezone
+	// name can't be mistaken for parts of the layout (e.g. the "Mon" in
+	// "Europe/Monaco").
+	return strings.ReplaceAll(t.Format(layout), "%tz", timezone)
+}
+
+// FormatFrontMatter formats a YAML front matter block (without a trailing
+// newline) as the header for the snippet file containing snippets timestamped
+// at t, for static site generators. Unlike headers formatted with
+// [FormatHeader], it spans several lines.
+func FormatFrontMatter(t time.Time, timezone string) string {
+	return strings.Join([]string{
+		FrontMatterDelimiter,
+		"date: " + t.Local().Format(time.DateOnly),
+		"timezone: " + timezone,
+		FrontMatterDelimiter,
+	}, "\n")
+}
+
+// MarkHeader returns header as it should be written to a snippet file, i.e.
+// with HeaderMarker in front if it starts with DefaultHeaderPrefix, which is
+// what snippets are most likely to be mistaken for. Other headers, such as
+// Markdown headings, are left alone, so that they still render and can be
+// searched for. So are front matter blocks, as they must start with the
+// delimiter to be recognized by static site generators.
+func MarkHeader(header string) string {
+	if !strings.HasPrefix(header, DefaultHeaderPrefix) || IsFrontMatter([]byte(header)) {
+		return header
+	}
+	return HeaderMarker + header
+}
+
+// IsFrontMatter reports whether header, e.g. as returned by [SplitHeader], is
+// a front matter block rather than a header line.
+func IsFrontMatter(header []byte) bool {
+	_, ok := frontMatterEnd(header, headerStart(header))
+	return ok
+}
+
+// frontMatterEnd returns the offset in contents right after the line closing
+// the front matter block starting at offset start. If there is no front matter
+// block there, ok is false.
+func frontMatterEnd(contents []byte, start int) (end int, ok bool) {
+	first := true
+	for offset := start; offset < len(contents); {
+		line := contents[offset:]
+		next := len(contents)
+		if i := bytes.IndexByte(line, '\n'); i != -1 {
+			line, next = line[:i], offset+i+1
+		}
+		isDelimiter := string(bytes.TrimRight(line, " \t\r")) == FrontMatterDelimiter
+		if first && !isDelimiter {
+			return 0, false
+		}
+		if !first && isDelimiter {
+			return next, true
+		}
+		first = false
+		offset = next
+	}
+	return 0, false
+}
+
+// utf8BOM is the UTF-8 byte order mark, which some editors write at the start
+// of files.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// headerStart returns the offset in contents where the header would start,
+// skipping a leading UTF-8 byte order mark and whitespace.
+func headerStart(contents []byte) int {
+	rest := bytes.TrimPrefix(contents, utf8BOM)
+	rest = bytes.TrimLeft(rest, " \t\r\n")
+	return len(contents) - len(rest)
+}
+
+// HasHeader reports whether the contents of a snippet file start with a header
+// line, i.e. with HeaderMarker, or with a front matter block (see
+// [FormatFrontMatter]). We won't try to parse the header into a date, as that
+// is too fragile. A leading byte order mark or whitespace is ignored.
+//
+// Files written before HeaderMarker was introduced have headers without it.
+// For those, we look for whether the file starts with prefix instead, which we
+// use as a proxy for "does the file contain the header".
+func HasHeader(contents []byte, prefix string) bool {
+	start := headerStart(contents)
+	if _, ok := frontMatterEnd(contents, start); ok {
+		return true
+	}
+	rest := contents[start:]
+	if bytes.HasPrefix(rest, []byte(HeaderMarker)) {
+		return true
+	}
+	return bytes.HasPrefix(rest, []byte(prefix))
+}
+
+// SplitHeader splits the contents of a snippet file into the header line, or
+// front matter block, (including its trailing newline, if any, and anything
+// ignored before it by [HasHeader]) and the remaining contents. If the file
+// doesn't start with a header, the returned header is nil.
+func SplitHeader(contents []byte, prefix string) (header, rest []byte) {
+	if !HasHeader(contents, prefix) {
+		return nil, contents
+	}
+	start := headerStart(contents)
+	if end, ok := frontMatterEnd(contents, start); ok {
+		return contents[:end], contents[end:]
+	}
+	idx := bytes.IndexByte(contents[start:], '\n')
+	if idx == -1 {
+		return contents, nil
+	}
+	return contents[:start+idx+1], contents[start+idx+1:]
+}
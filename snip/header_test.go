@@ -0,0 +1,122 @@
+package snip
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHasHeader(t *testing.T) {
+	const header = "--- Wednesday Nov 20 2024 in UTC ---\n"
+	for _, tt := range []struct {
+		name     string
+		contents string
+		want     bool
+	}{
+		{name: "empty", contents: "", want: false},
+		{name: "marked header", contents: HeaderMarker + header, want: true},
+		{name: "unmarked header", contents: header + "09:00 | text\n", want: true},
+		{name: "no header", contents: "09:00 | text\n", want: false},
+		{name: "byte order mark", contents: "\uFEFF" + header, want: true},
+		{name: "byte order mark and marker", contents: "\uFEFF" + HeaderMarker + header, want: true},
+		{name: "leading whitespace", contents: "\n  \r\n\t" + header, want: true},
+		{name: "byte order mark and whitespace", contents: "\uFEFF\n " + header, want: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasHeader([]byte(tt.contents), DefaultHeaderPrefix); got != tt.want {
+				t.Errorf("HasHeader(%q) = %v, want %v", tt.contents, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkHeader(t *testing.T) {
+	for _, tt := range []struct {
+		header string
+		want   string
+	}{
+		{header: "--- Wednesday Nov 20 2024 in UTC ---", want: HeaderMarker + "--- Wednesday Nov 20 2024 in UTC ---"},
+		{header: "# 2024-11-20", want: "# 2024-11-20"},
+		{header: "2024-11-20", want: "2024-11-20"},
+		{header: "---\ndate: 2024-11-20\n---", want: "---\ndate: 2024-11-20\n---"},
+	} {
+		if got := MarkHeader(tt.header); got != tt.want {
+			t.Errorf("MarkHeader(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+	// Unmarked headers are still recognized by their prefix, so they aren't
+	// added again.
+	contents := Assemble(nil, [][]byte{[]byte("09:00 | a")}, AssembleOptions{Header: "# 2024-11-20", HeaderPrefix: "#"})
+	contents = Assemble(contents, [][]byte{[]byte("10:00 | b")}, AssembleOptions{Header: "# 2024-11-20", HeaderPrefix: "#"})
+	if want := "# 2024-11-20\n09:00 | a\n10:00 | b\n"; string(contents) != want {
+		t.Errorf("Assemble twice = %q, want %q", contents, want)
+	}
+}
+
+func TestAssembleNoDoubleHeader(t *testing.T) {
+	const header = "--- Wednesday Nov 20 2024 in UTC ---"
+	for _, existing := range []string{
+		"\uFEFF" + header + "\n09:00 | old\n",
+		"\n\n" + header + "\n09:00 | old\n",
+		"\uFEFF  \n" + HeaderMarker + header + "\n09:00 | old\n",
+	} {
+		got := Assemble([]byte(existing), [][]byte{[]byte("10:00 | new")}, AssembleOptions{Header: header})
+		if n := bytes.Count(got, []byte(header)); n != 1 {
+			t.Errorf("Assemble(%q) = %q, which has %d headers, want 1", existing, got, n)
+		}
+		if header, rest := SplitHeader(got, DefaultHeaderPrefix); string(rest) != "09:00 | old\n10:00 | new\n" {
+			t.Errorf("SplitHeader(%q) = %q, %q, want the header and the snippets", got, header, rest)
+		}
+	}
+}
+
+func TestSplitHeaderSnippetsStartingWithPrefix(t *testing.T) {
+	const header = HeaderMarker + "--- Wednesday Nov 20 2024 in UTC ---\n"
+	for _, rest := range []string{
+		"---\n",
+		"--- not a header\n09:00 | --- also not\n",
+	} {
+		gotHeader, gotRest := SplitHeader([]byte(header+rest), DefaultHeaderPrefix)
+		if string(gotHeader) != header || string(gotRest) != rest {
+			t.Errorf("SplitHeader(%q) = %q, %q; want %q, %q", header+rest, gotHeader, gotRest, header, rest)
+		}
+	}
+	// A new snippet starting with the prefix doesn't count as a header when
+	// the next one is added.
+	contents := Assemble(nil, [][]byte{[]byte("--- rule")}, AssembleOptions{Header: "--- Wednesday Nov 20 2024 in UTC ---"})
+	contents = Assemble(contents, [][]byte{[]byte("---")}, AssembleOptions{Header: "--- Wednesday Nov 20 2024 in UTC ---"})
+	if want := header + "--- rule\n---\n"; string(contents) != want {
+		t.Errorf("Assemble twice = %q, want %q", contents, want)
+	}
+}
+
+func TestAppendFrontMatterOnce(t *testing.T) {
+	dir := t.TempDir()
+	a := &Appender{
+		Dir:    dir,
+		Header: func(t time.Time) string { return FormatFrontMatter(t, "Europe/Stockholm") },
+	}
+	date := time.Date(2024, time.November, 20, 9, 0, 0, 0, time.Local)
+	var res AppendResult
+	for _, line := range []string{"09:00 | first", "10:00 | second", "11:00 | third"} {
+		var err error
+		res, err = a.Append(context.Background(), AppendOptions{Lines: [][]byte{[]byte(line)}, Time: date})
+		if err != nil {
+			t.Fatalf("Append(%q) failed: %v", line, err)
+		}
+	}
+	got, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "---\ndate: 2024-11-20\ntimezone: Europe/Stockholm\n---\n09:00 | first\n10:00 | second\n11:00 | third\n"
+	if string(got) != want {
+		t.Errorf("snippet file after three appends:\n%s\nwant:\n%s", got, want)
+	}
+	header, _ := SplitHeader(got, DefaultHeaderPrefix)
+	if !IsFrontMatter(header) {
+		t.Errorf("SplitHeader(%q) = %q, want the front matter", got, header)
+	}
+}
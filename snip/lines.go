@@ -0,0 +1,187 @@
+package snip
+
+import (
+	"bytes"
+	"strings"
+)
+
+// TimestampSeparator separates the timestamp prefix from the snippet text in
+// a snippet line, e.g. "15:04 | worked on the API draft".
+const TimestampSeparator = " | "
+
+// ContinuationIndent is the indentation of the continuation lines of a
+// snippet spanning several lines. Any line starting with it is considered to
+// be part of the snippet on the previous line.
+const ContinuationIndent = "  "
+
+// CodeFence opens and closes a block of continuation lines that are kept
+// verbatim, including blank lines, e.g. code or a stack trace:
+//
+//	09:15 | restart the ingester
+//	  ```
+//	  kubectl rollout restart deploy/ingester
+//
+//	  kubectl get pods -w
+//	  ```
+//
+// Blank lines inside the block are written as ContinuationIndent, so that
+// they're recognized as part of the snippet.
+const CodeFence = "```"
+
+// ContinueSeparator separates the text added to an existing snippet with
+// AppendOptions.Continue from the snippet's original text.
+const ContinueSeparator = "; "
+
+// SplitTimestamp splits a snippet line into its timestamp prefix and the
+// snippet text, at the first occurrence of any of the given separators. If no
+// separators are given, TimestampSeparator is used. Accepting several
+// separators makes it possible to read snippet files where the separator was
+// changed at some point. If the line has no timestamp prefix, ok is false.
+func SplitTimestamp(line string, separators ...string) (prefix, text string, ok bool) {
+	if len(separators) == 0 {
+		separators = []string{TimestampSeparator}
+	}
+	idx, sep := -1, ""
+	for _, s := range separators {
+		if s == "" {
+			continue
+		}
+		if i := strings.Index(line, s); i != -1 && (idx == -1 || i < idx) {
+			idx, sep = i, s
+		}
+	}
+	if idx == -1 {
+		return "", line, false
+	}
+	return line[:idx], line[idx+len(sep):], true
+}
+
+// Lines returns the snippets in the contents of a snippet file, excluding the
+// header (recognized by headerPrefix), divider lines, and blank lines. Usually each snippet is
+// a single line, but snippets spanning several lines also include their
+// continuation lines (separated by newlines). Blank lines inside a code block
+// (see [CodeFence]) are kept as part of the snippet.
+func Lines(contents []byte, headerPrefix string) [][]byte {
+	_, rest := SplitHeader(contents, headerPrefix)
+	var lines [][]byte
+	inFence := false
+	for _, line := range bytes.Split(rest, []byte{'\n'}) {
+		blank := len(bytes.TrimSpace(line)) == 0
+		n := len(lines)
+		if inFence && (blank || bytes.HasPrefix(line, []byte(ContinuationIndent))) {
+			lines[n-1] = append(append(lines[n-1], '\n'), line...)
+			inFence = !isFence(line)
+			continue
+		}
+		inFence = false
+		if blank || IsDivider(line) {
+			continue
+		}
+		if n != 0 && bytes.HasPrefix(line, []byte(ContinuationIndent)) {
+			lines[n-1] = append(append(lines[n-1], '\n'), line...)
+			inFence = isFence(line)
+			continue
+		}
+		// Copy the line, so that appending continuation lines to it doesn't
+		// overwrite the contents.
+		lines = append(lines, bytes.Clone(line))
+	}
+	return lines
+}
+
+// isFence reports whether line is a continuation line opening or closing a
+// code block.
+func isFence(line []byte) bool {
+	return bytes.HasPrefix(line, []byte(ContinuationIndent)) && bytes.Equal(bytes.TrimSpace(line), []byte(CodeFence))
+}
+
+// LastSnippet returns the start and end offsets (excluding the trailing
+// newline) of the last snippet in snippets, i.e. the last non-blank line
+// together with any continuation lines preceding it. Divider lines (see
+// [IsDivider]) are skipped. If there is no such line, ok is false.
+func LastSnippet(snippets []byte) (start, end int, ok bool) {
+	end = len(snippets)
+	for {
+		end = len(bytes.TrimRight(snippets[:end], " \t\r\n"))
+		if end == 0 {
+			return 0, 0, false
+		}
+		start = bytes.LastIndexByte(snippets[:end], '\n') + 1
+		if !IsDivider(snippets[start:end]) {
+			break
+		}
+		end = start
+	}
+	for start > 0 && bytes.HasPrefix(snippets[start:], []byte(ContinuationIndent)) {
+		start = bytes.LastIndexByte(snippets[:start-1], '\n') + 1
+	}
+	return start, end, true
+}
+
+// NthSnippet returns the start and end offsets (excluding the trailing
+// newline) of the nth snippet in snippets, counting from 1, including its
+// continuation lines. Snippets are counted like by [Lines]. If there are fewer
+// than n snippets, ok is false.
+func NthSnippet(snippets []byte, n int) (start, end int, ok bool) {
+	count := 0
+	inFence := false
+	for offset := 0; offset < len(snippets); {
+		line, _, _ := bytes.Cut(snippets[offset:], []byte{'\n'})
+		lineEnd := offset + len(line)
+		blank := len(bytes.TrimSpace(line)) == 0
+		switch {
+		case inFence && (blank || bytes.HasPrefix(line, []byte(ContinuationIndent))):
+			inFence = !isFence(line)
+			if count == n {
+				end = lineEnd
+			}
+		case blank || IsDivider(line):
+			inFence = false
+		case count != 0 && bytes.HasPrefix(line, []byte(ContinuationIndent)):
+			inFence = isFence(line)
+			if count == n {
+				end = lineEnd
+			}
+		default:
+			inFence = false
+			if count == n && n != 0 {
+				return start, end, true
+			}
+			count++
+			start, end = offset, lineEnd
+		}
+		offset = lineEnd + 1
+	}
+	return start, end, n >= 1 && count == n
+}
+
+// dividerMarker surrounds the label of a divider line, e.g. "-- 14:00 --".
+const dividerMarker = "--"
+
+// FormatDivider formats a divider line (without a trailing newline) with the
+// given label, e.g. "-- 14:00 --". Dividers group the snippets in a snippet
+// file under sub-headings, and aren't snippets themselves.
+func FormatDivider(label string) string {
+	return dividerMarker + " " + label + " " + dividerMarker
+}
+
+// IsDivider reports whether line is a divider line, as formatted by
+// [FormatDivider].
+func IsDivider(line []byte) bool {
+	line = bytes.TrimRight(line, " \t\r")
+	marker := []byte(dividerMarker + " ")
+	return bytes.HasPrefix(line, marker) && bytes.HasSuffix(line, []byte(" "+dividerMarker)) && len(line) > 2*len(marker)
+}
+
+// lastDivider returns the last divider line in contents, or nil if there is
+// none.
+func lastDivider(contents []byte) []byte {
+	for len(contents) != 0 {
+		start := bytes.LastIndexByte(contents, '\n') + 1
+		if line := contents[start:]; IsDivider(line) {
+			return bytes.TrimRight(line, " \t\r")
+		}
+		contents = contents[:max(start-1, 0)]
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package snip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// LockFileName is the name of the lock file taken by [Lock].
+	LockFileName = ".lock"
+	// lockTimeout is how long to wait for another process to release the lock
+	// before giving up.
+	lockTimeout = 5 * time.Second
+	// lockRetryInterval is how long to wait between attempts to take the lock.
+	lockRetryInterval = 50 * time.Millisecond
+)
+
+// Lock takes an exclusive advisory lock on the snippet files in dir, to be held
+// across a read-modify-write of a snippet file. Without it, two processes
+// running at the same time could both read the same snippet file, and the one
+// writing it last would overwrite the other's changes. The returned function
+// releases the lock.
+//
+// Lock gives up if ctx is done, or if the lock isn't released by another
+// process within a few seconds. If dir doesn't exist yet, there are no snippet
+// files to protect, and no lock is taken.
+func Lock(ctx context.Context, dir string) (unlock func(), err error) {
+	path := filepath.Join(dir, LockFileName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, fs.FileMode(0o600))
+	if errors.Is(err, fs.ErrNotExist) {
+		return func() {}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("lock snippets: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+	for {
+		ok, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("lock snippets: %s: %w", path, err)
+		}
+		if ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			f.Close()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("lock snippets: %s: timed out after %v waiting for another snip process to finish", path, lockTimeout)
+			}
+			return nil, fmt.Errorf("lock snippets: %s: %v", path, ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+	return func() {
+		// Closing the file releases the lock.
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package snip
+
+import "os"
+
+// tryLockFile is a no-op on platforms without flock(2), where [Lock] doesn't
+// protect against concurrent writes.
+func tryLockFile(f *os.File) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package snip
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLockFile tries to take an exclusive lock on f without blocking. It
+// returns false if the lock is held by someone else.
+func tryLockFile(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
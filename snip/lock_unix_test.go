@@ -0,0 +1,61 @@
+//go:build unix
+
+package snip
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockSequentialHolders(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	unlock, err := Lock(ctx, dir)
+	if err != nil {
+		t.Fatalf("first Lock failed: %v", err)
+	}
+
+	// While the first holder has the lock, a second one has to wait.
+	waitCtx, cancel := context.WithTimeout(ctx, 5*lockRetryInterval)
+	defer cancel()
+	if unlock2, err := Lock(waitCtx, dir); err == nil {
+		unlock2()
+		t.Fatal("second Lock succeeded while the first holder had the lock")
+	}
+
+	// Once it's released, the second one gets it.
+	unlock()
+	unlock2, err := Lock(ctx, dir)
+	if err != nil {
+		t.Fatalf("second Lock after the first was released failed: %v", err)
+	}
+	unlock2()
+}
+
+func TestLockWaitsForRelease(t *testing.T) {
+	dir := t.TempDir()
+	unlock, err := Lock(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("first Lock failed: %v", err)
+	}
+	time.AfterFunc(3*lockRetryInterval, unlock)
+	start := time.Now()
+	unlock2, err := Lock(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("second Lock failed: %v", err)
+	}
+	unlock2()
+	if waited := time.Since(start); waited < 3*lockRetryInterval {
+		t.Errorf("second Lock returned after %v, before the first holder released the lock", waited)
+	}
+}
+
+func TestLockMissingDir(t *testing.T) {
+	unlock, err := Lock(context.Background(), filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("Lock of a missing directory failed: %v", err)
+	}
+	unlock()
+}
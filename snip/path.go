@@ -0,0 +1,166 @@
+package snip
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Granularity determines how snippets are grouped into snippet files.
+type Granularity string
+
+const (
+	// Day groups snippets into one file per day, e.g. 2006-01-02.txt.
+	Day Granularity = "day"
+	// Week groups snippets into one file per ISO week, e.g. 2006-W01.txt.
+	Week Granularity = "week"
+	// Month groups snippets into one file per month, e.g. 2006-01.txt.
+	Month Granularity = "month"
+)
+
+// Layout determines how snippet files are arranged in their directory.
+type Layout string
+
+const (
+	// Flat stores each snippet file directly in the directory, e.g.
+	// 2006-01-02.txt.
+	Flat Layout = "flat"
+	// Dir stores each snippet file in a directory of its own, e.g.
+	// 2006-01-02/snippets.txt, next to any files attached to the snippets.
+	Dir Layout = "dir"
+)
+
+// DirSnippetFile is the name of the snippet file inside its directory with
+// the Dir layout.
+const DirSnippetFile = "snippets.txt"
+
+// LayoutPath returns the path of the snippet file at path, as returned by
+// [SnippetPath], in the given layout. For Dir, e.g. "2006-01-02.txt" becomes
+// "2006-01-02/snippets.txt".
+func LayoutPath(path string, l Layout) string {
+	if l != Dir {
+		return path
+	}
+	return filepath.Join(strings.TrimSuffix(path, ".txt"), DirSnippetFile)
+}
+
+// SnippetPath is the path of the file in dir where a snippet timestamped at t
+// should be written to. The date is that of t in the local timezone.
+func SnippetPath(dir string, g Granularity, t time.Time) (string, error) {
+	if t.IsZero() {
+		return "", fmt.Errorf("resolve snippet path: timestamp is zero")
+	}
+	t = t.Local()
+	var name string
+	switch g {
+	case Day:
+		name = t.Format(time.DateOnly)
+	case Week:
+		year, week := t.ISOWeek()
+		name = fmt.Sprintf("%04d-W%02d", year, week)
+	case Month:
+		name = t.Format("2006-01")
+	default:
+		return "", fmt.Errorf("resolve snippet path: unknown granularity %q", g)
+	}
+	if err := CheckFileName(name); err != nil {
+		return "", fmt.Errorf("resolve snippet path: %w", err)
+	}
+	return filepath.Join(dir, name+".txt"), nil
+}
+
+// CheckFileName returns an error if name, which is typically the result of
+// formatting a timestamp, is not safe to use as the name of a file inside the
+// base directory. For example, a layout like "2006/01/02" would produce a name
+// that escapes into subdirectories.
+func CheckFileName(name string) error {
+	if name == "" || name == "." || name == ".." {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+	if i := strings.IndexAny(name, `/\:`+"\x00"); i != -1 {
+		return fmt.Errorf("invalid file name %q: contains path-unsafe character %q", name, name[i])
+	}
+	return nil
+}
+
+// Files returns the paths of all snippet files in dir, in either layout,
+// sorted by their names (see [FileName]). Since snippet files are named after
+// their date, this means they are sorted chronologically.
+func Files(dir string) ([]string, error) {
+	var paths []string
+	for _, pattern := range []string{
+		"*.txt",
+		"*.txt" + EncryptedExt,
+		filepath.Join("*", DirSnippetFile),
+		filepath.Join("*", DirSnippetFile+EncryptedExt),
+	} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("find snippet files: %w", err)
+		}
+		paths = append(paths, matches...)
+	}
+	// filepath.Glob returns the matches in lexical order, but the matches of
+	// the different patterns need to be sorted in among each other.
+	slices.SortFunc(paths, func(a, b string) int {
+		return cmp.Or(cmp.Compare(FileName(a), FileName(b)), cmp.Compare(a, b))
+	})
+	return paths, nil
+}
+
+// FileName returns the name of the snippet file at path without the
+// extension, e.g. "2024-01-15" for a daily snippet file, whether it's
+// encrypted or not. With the Dir layout, it's the name of the directory
+// containing the snippet file.
+func FileName(path string) string {
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), EncryptedExt), ".txt")
+	if name+".txt" == DirSnippetFile {
+		return filepath.Base(filepath.Dir(path))
+	}
+	return name
+}
+
+// FileDate parses the date of the snippet file at path from its name: the day
+// of a daily snippet file, the Monday of a weekly one, or the first day of a
+// monthly one (see [Granularity]). If the file name isn't a day, week or month,
+// ok is false.
+func FileDate(path string) (date time.Time, ok bool) {
+	name := FileName(path)
+	if date, err := time.ParseInLocation(time.DateOnly, name, time.Local); err == nil {
+		return date, true
+	}
+	if date, err := time.ParseInLocation("2006-01", name, time.Local); err == nil {
+		return date, true
+	}
+	return weekStart(name)
+}
+
+// weekStart parses name as an ISO week like "2006-W01" and returns the Monday
+// of that week.
+func weekStart(name string) (time.Time, bool) {
+	y, w, found := strings.Cut(name, "-W")
+	if !found || len(y) != 4 || len(w) != 2 {
+		return time.Time{}, false
+	}
+	year, err := strconv.Atoi(y)
+	if err != nil {
+		return time.Time{}, false
+	}
+	week, err := strconv.Atoi(w)
+	if err != nil || week < 1 {
+		return time.Time{}, false
+	}
+	// January 4th is always in the first week of the year.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.Local)
+	monday := jan4.AddDate(0, 0, -(int(jan4.Weekday())+6)%7+(week-1)*7)
+	// Years have 52 or 53 weeks, so e.g. week 53 might be week 1 of the next
+	// year.
+	if gotYear, gotWeek := monday.ISOWeek(); gotYear != year || gotWeek != week {
+		return time.Time{}, false
+	}
+	return monday, true
+}
@@ -0,0 +1,82 @@
+package snip
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileDate(t *testing.T) {
+	for _, tt := range []struct {
+		path   string
+		want   time.Time
+		wantOK bool
+	}{
+		{path: "2024-11-20.txt", want: time.Date(2024, time.November, 20, 0, 0, 0, 0, time.Local), wantOK: true},
+		{path: "2024-11-20.txt" + EncryptedExt, want: time.Date(2024, time.November, 20, 0, 0, 0, 0, time.Local), wantOK: true},
+		{path: filepath.Join("2024-11-20", DirSnippetFile), want: time.Date(2024, time.November, 20, 0, 0, 0, 0, time.Local), wantOK: true},
+		{path: "2026-W42.txt", want: time.Date(2026, time.October, 12, 0, 0, 0, 0, time.Local), wantOK: true},
+		// Week 1 of 2025 starts in 2024.
+		{path: "2025-W01.txt", want: time.Date(2024, time.December, 30, 0, 0, 0, 0, time.Local), wantOK: true},
+		{path: "2020-W53.txt", want: time.Date(2020, time.December, 28, 0, 0, 0, 0, time.Local), wantOK: true},
+		{path: "2026-10.txt", want: time.Date(2026, time.October, 1, 0, 0, 0, 0, time.Local), wantOK: true},
+		{path: "2021-W53.txt"},
+		{path: "2026-W00.txt"},
+		{path: "2026-W4.txt"},
+		{path: "2026-13.txt"},
+		{path: "2024-11-31.txt"},
+		{path: "notes.txt"},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := FileDate(tt.path)
+			if ok != tt.wantOK {
+				t.Fatalf("FileDate(%q) ok = %v, want %v", tt.path, ok, tt.wantOK)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("FileDate(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileDateRoundTrip(t *testing.T) {
+	// The date of a snippet file must be in the period the file is for.
+	for _, g := range []Granularity{Day, Week, Month} {
+		for d := time.Date(2020, time.December, 20, 12, 0, 0, 0, time.Local); d.Year() < 2022; d = d.AddDate(0, 0, 1) {
+			path, err := SnippetPath("", g, d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := FileDate(path)
+			if !ok {
+				t.Fatalf("FileDate(%q) failed", path)
+			}
+			if again, _ := SnippetPath("", g, got); again != path {
+				t.Errorf("FileDate(%q) = %v, which is in %q", path, got, again)
+			}
+		}
+	}
+}
+
+func TestCheckFileName(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "2024-11-20"},
+		{name: "2024-W47"},
+		{name: "2024-11"},
+		{name: "Wednesday 20 Nov"},
+		{name: "2024/11/20", wantErr: true},
+		{name: `2024\11\20`, wantErr: true},
+		{name: "15:04", wantErr: true},
+		{name: "2024\x0011", wantErr: true},
+		{name: "", wantErr: true},
+		{name: ".", wantErr: true},
+		{name: "..", wantErr: true},
+	} {
+		if err := CheckFileName(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("CheckFileName(%q) = %v, want error: %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package snip
+
+import (
+	"errors"
+	"log/slog"
+	"syscall"
+	"time"
+)
+
+// retryBaseDelay is the delay before the first retry in [Retry]. It doubles
+// for every retry after that.
+var retryBaseDelay = 50 * time.Millisecond
+
+// transientErrnos are the errors considered transient by [Retry], e.g. from
+// network filesystems timing out or being interrupted. Anything else, like
+// ENOSPC or EACCES, won't go away by retrying.
+var transientErrnos = []syscall.Errno{
+	syscall.EINTR,
+	syscall.EAGAIN,
+	syscall.ETIMEDOUT,
+	syscall.EBUSY,
+	syscall.ESTALE,
+}
+
+// Retry calls op, and if it fails with a transient error (see
+// [IsTransient]), retries it up to retries times, with exponential backoff.
+// It returns the error from the last call.
+func Retry(retries int, op func() error) error {
+	delay := retryBaseDelay
+	for i := 0; ; i++ {
+		err := op()
+		if err == nil || i >= retries || !IsTransient(err) {
+			return err
+		}
+		slog.Warn("Transient I/O error, retrying", "error", err, "delay", delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// IsTransient reports whether err is an I/O error that might go away if the
+// operation is retried, e.g. EINTR or ETIMEDOUT.
+func IsTransient(err error) bool {
+	for _, errno := range transientErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package snip
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	previous := retryBaseDelay
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = previous })
+
+	transient := fmt.Errorf("read: %w", syscall.ETIMEDOUT)
+	permanent := fmt.Errorf("read: %w", syscall.EACCES)
+	for _, tt := range []struct {
+		name      string
+		retries   int
+		errs      []error // returned by the calls in order; nil after that
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "success", retries: 2, wantCalls: 1},
+		{name: "transient then success", retries: 2, errs: []error{transient, transient}, wantCalls: 3},
+		{name: "transient until out of retries", retries: 2, errs: []error{transient, transient, transient}, wantErr: transient, wantCalls: 3},
+		{name: "no retries", retries: 0, errs: []error{transient}, wantErr: transient, wantCalls: 1},
+		{name: "permanent", retries: 2, errs: []error{permanent}, wantErr: permanent, wantCalls: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := Retry(tt.retries, func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("Retry() = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Retry() called the operation %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+// Package snip implements reading and writing snippet files, which is where
+// the snip command records snippets, i.e. short notes with a timestamp.
+//
+// Snippet files are plain text files in a directory, named after the date of
+// the snippets in them (e.g. 2024-01-15.txt). Each file starts with an
+// optional header line, followed by the snippets, one per line, each
+// optionally prefixed with a timestamp:
+//
+//	--- Monday Jan 15 2024 in Europe/Stockholm ---
+//	09:30 | at desk; going to review Alice's MR
+//	09:53 | reviewed the MR
+//
+// Snippets can span several lines, in which case all lines but the first are
+// indented with [ContinuationIndent]. Continuation lines can also form a
+// block that's kept verbatim; see [CodeFence].
+//
+// This package doesn't know about the flags of the snip command; everything is
+// configured through arguments and struct fields.
+package snip
@@ -0,0 +1,24 @@
+package snip
+
+import (
+	"os"
+	"time"
+)
+
+// InferLocalTimezone attempts to figure out the IANA name of the local timezone
+// (e.g. "Europe/Stockholm" or "America/Los_Angeles"). It's done on best effort
+// basis, since macOS doesn't provide any explicit way to query for it.
+//
+// This function uses the value of the TZ environment variable, if set, as long
+// as it is a valid location according to [time.LoadLocation]. Otherwise, the
+// timezone configured in the operating system is used.
+func InferLocalTimezone() (string, error) {
+	// Let the TZ environment variable take precedence, if it's set and resolves
+	// to a valid timezone using [time.LoadLocation].
+	if tz := os.Getenv("TZ"); tz != "" {
+		if _, err := time.LoadLocation(tz); err == nil { // if NO error
+			return tz, nil
+		}
+	}
+	return inferSystemTimezone()
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+
+package snip
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// inferSystemTimezone infers the IANA name of the timezone configured in the
+// operating system from the /etc/localtime symlink.
+func inferSystemTimezone() (string, error) {
+	// Best-effort: assume that /etc/localtime is a symlink to a file whose path
+	// contains the timezone name in a standardized format. On my macOS system,
+	// it looks like this:
+	//
+	//     $ readlink /etc/localtime
+	//     /var/db/timezone/zoneinfo/Europe/London
+	//
+	// To be a bit more liberal in the paths accepted, look for a "zoneinfo/"
+	// substring, and assume everything after it is the timezone name.
+	//
+	// As a sanity check, try loading the inferred timezone with
+	// [time.LoadLocation]. If that doesn't work, return an error.
+	const localtime = "/etc/localtime"
+	realPath, err := filepath.EvalSymlinks(localtime)
+	if err != nil {
+		return "", fmt.Errorf("infer local timezone: evaluate %s as a symlink: %w", localtime, err)
+	}
+	const marker = "zoneinfo/"
+	idx := strings.Index(realPath, marker)
+	if idx == -1 {
+		return "", fmt.Errorf("infer local timezone: infer from %s symlink: real path does not contain %q", localtime, marker)
+	}
+	inferred := realPath[idx+len(marker):]
+	if _, err := time.LoadLocation(inferred); err != nil {
+		return "", fmt.Errorf("infer local timezone: infer from %s symlink: inferred timezone %q cannot be loaded with time.LoadLocation: %w", localtime, inferred, err)
+	}
+	return inferred, nil
+}
@@ -0,0 +1,117 @@
+//go:build windows
+
+package snip
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	// Windows doesn't ship IANA timezone data, which [time.LoadLocation]
+	// needs to check the inferred timezone.
+	_ "time/tzdata"
+)
+
+// inferSystemTimezone infers the IANA name of the timezone configured in
+// Windows. Windows has its own timezone names (e.g. "W. Europe Standard Time"),
+// which are queried with tzutil and mapped to IANA names using
+// windowsTimezones.
+func inferSystemTimezone() (string, error) {
+	out, err := exec.Command("tzutil", "/g").Output()
+	if err != nil {
+		return "", fmt.Errorf("infer local timezone: run tzutil: %w", err)
+	}
+	return windowsToIANA(string(out))
+}
+
+// windowsToIANA maps the name of a Windows timezone, as printed by tzutil, to
+// its IANA name using windowsTimezones.
+func windowsToIANA(name string) (string, error) {
+	// Daylight saving time can be disabled for a timezone, in which case
+	// tzutil adds a suffix to the name.
+	name = strings.TrimSuffix(strings.TrimSpace(name), "_dstoff")
+	inferred, ok := windowsTimezones[name]
+	if !ok {
+		return "", fmt.Errorf("infer local timezone: unknown Windows timezone %q", name)
+	}
+	if _, err := time.LoadLocation(inferred); err != nil {
+		return "", fmt.Errorf("infer local timezone: inferred timezone %q cannot be loaded with time.LoadLocation: %w", inferred, err)
+	}
+	return inferred, nil
+}
+
+// windowsTimezones maps Windows timezone names to IANA timezone names, based on
+// the "001" (i.e. default) territory of the mapping in the Unicode CLDR
+// (https://github.com/unicode-org/cldr/blob/main/common/supplemental/windowsZones.xml).
+// Only the most common timezones are included.
+var windowsTimezones = map[string]string{
+	"Dateline Standard Time":          "Etc/GMT+12",
+	"UTC-11":                          "Etc/GMT+11",
+	"Aleutian Standard Time":          "America/Adak",
+	"Hawaiian Standard Time":          "Pacific/Honolulu",
+	"Alaskan Standard Time":           "America/Anchorage",
+	"Pacific Standard Time (Mexico)":  "America/Tijuana",
+	"Pacific Standard Time":           "America/Los_Angeles",
+	"US Mountain Standard Time":       "America/Phoenix",
+	"Mountain Standard Time (Mexico)": "America/Mazatlan",
+	"Mountain Standard Time":          "America/Denver",
+	"Central America Standard Time":   "America/Guatemala",
+	"Central Standard Time":           "America/Chicago",
+	"Central Standard Time (Mexico)":  "America/Mexico_City",
+	"Canada Central Standard Time":    "America/Regina",
+	"SA Pacific Standard Time":        "America/Bogota",
+	"Eastern Standard Time":           "America/New_York",
+	"US Eastern Standard Time":        "America/Indiana/Indianapolis",
+	"Venezuela Standard Time":         "America/Caracas",
+	"Atlantic Standard Time":          "America/Halifax",
+	"SA Western Standard Time":        "America/La_Paz",
+	"Pacific SA Standard Time":        "America/Santiago",
+	"Newfoundland Standard Time":      "America/St_Johns",
+	"E. South America Standard Time":  "America/Sao_Paulo",
+	"Argentina Standard Time":         "America/Argentina/Buenos_Aires",
+	"SA Eastern Standard Time":        "America/Cayenne",
+	"Greenland Standard Time":         "America/Nuuk",
+	"UTC-02":                          "Etc/GMT+2",
+	"Azores Standard Time":            "Atlantic/Azores",
+	"Cape Verde Standard Time":        "Atlantic/Cape_Verde",
+	"UTC":                             "Etc/UTC",
+	"GMT Standard Time":               "Europe/London",
+	"Greenwich Standard Time":         "Atlantic/Reykjavik",
+	"W. Europe Standard Time":         "Europe/Berlin",
+	"Central Europe Standard Time":    "Europe/Budapest",
+	"Romance Standard Time":           "Europe/Paris",
+	"Central European Standard Time":  "Europe/Warsaw",
+	"W. Central Africa Standard Time": "Africa/Lagos",
+	"GTB Standard Time":               "Europe/Bucharest",
+	"E. Europe Standard Time":         "Europe/Chisinau",
+	"Egypt Standard Time":             "Africa/Cairo",
+	"FLE Standard Time":               "Europe/Kyiv",
+	"Israel Standard Time":            "Asia/Jerusalem",
+	"South Africa Standard Time":      "Africa/Johannesburg",
+	"Turkey Standard Time":            "Europe/Istanbul",
+	"Arab Standard Time":              "Asia/Riyadh",
+	"Russian Standard Time":           "Europe/Moscow",
+	"E. Africa Standard Time":         "Africa/Nairobi",
+	"Iran Standard Time":              "Asia/Tehran",
+	"Arabian Standard Time":           "Asia/Dubai",
+	"Afghanistan Standard Time":       "Asia/Kabul",
+	"Pakistan Standard Time":          "Asia/Karachi",
+	"India Standard Time":             "Asia/Kolkata",
+	"Nepal Standard Time":             "Asia/Kathmandu",
+	"Bangladesh Standard Time":        "Asia/Dhaka",
+	"SE Asia Standard Time":           "Asia/Bangkok",
+	"China Standard Time":             "Asia/Shanghai",
+	"Singapore Standard Time":         "Asia/Singapore",
+	"Taipei Standard Time":            "Asia/Taipei",
+	"W. Australia Standard Time":      "Australia/Perth",
+	"Tokyo Standard Time":             "Asia/Tokyo",
+	"Korea Standard Time":             "Asia/Seoul",
+	"Cen. Australia Standard Time":    "Australia/Adelaide",
+	"AUS Central Standard Time":       "Australia/Darwin",
+	"E. Australia Standard Time":      "Australia/Brisbane",
+	"AUS Eastern Standard Time":       "Australia/Sydney",
+	"Tasmania Standard Time":          "Australia/Hobart",
+	"New Zealand Standard Time":       "Pacific/Auckland",
+	"Tonga Standard Time":             "Pacific/Tongatapu",
+}
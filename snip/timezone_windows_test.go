@@ -0,0 +1,36 @@
+//go:build windows
+
+package snip
+
+import "testing"
+
+func TestWindowsToIANA(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		want string
+	}{
+		{name: "W. Europe Standard Time", want: "Europe/Berlin"},
+		{name: "Pacific Standard Time\r\n", want: "America/Los_Angeles"},
+		{name: "Eastern Standard Time_dstoff", want: "America/New_York"},
+	} {
+		got, err := windowsToIANA(tt.name)
+		if err != nil {
+			t.Errorf("windowsToIANA(%q) failed: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("windowsToIANA(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got, err := windowsToIANA("Mars Standard Time"); err == nil {
+		t.Errorf("windowsToIANA(%q) = %q, want error", "Mars Standard Time", got)
+	}
+}
+
+func TestWindowsTimezonesLoad(t *testing.T) {
+	for name := range windowsTimezones {
+		if _, err := windowsToIANA(name); err != nil {
+			t.Errorf("windowsToIANA(%q) failed: %v", name, err)
+		}
+	}
+}
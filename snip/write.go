@@ -0,0 +1,63 @@
+package snip
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"syscall"
+)
+
+// WriteFile atomically replaces the file at path with data, by writing it to a
+// temporary file that's then renamed over the original (see [Appender.Append]
+// for why).
+//
+// Some filesystems, e.g. some FUSE and network mounts, don't support the
+// rename that makes this atomic. If nonAtomicFallback is set and the rename
+// fails for that reason, the file is instead overwritten in place and synced,
+// and a warning is logged. A crash in the middle of that can leave the file
+// half-written, so it's only a fallback.
+func WriteFile(path string, data []byte, perm fs.FileMode, nonAtomicFallback bool) error {
+	err := atomicWrite(path, data, perm)
+	if err == nil || !nonAtomicFallback || !renameUnsupported(err) {
+		return err
+	}
+	slog.Warn("Atomic write not supported by the filesystem; writing the file in place instead", "path", path, "error", err)
+	return writeInPlace(path, data, perm)
+}
+
+// atomicWrite is writeFileAtomic. It's a variable so that tests can make the
+// rename fail.
+var atomicWrite = writeFileAtomic
+
+// renameUnsupported reports whether err is from a rename that failed because
+// the filesystem doesn't support it, as opposed to e.g. missing permissions.
+func renameUnsupported(err error) bool {
+	var linkErr *os.LinkError
+	if !errors.As(err, &linkErr) {
+		return false
+	}
+	for _, errno := range []syscall.Errno{syscall.EXDEV, syscall.ENOTSUP, syscall.EOPNOTSUPP, syscall.ENOSYS} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeInPlace overwrites the file at path with data and syncs it to disk.
+func writeInPlace(path string, data []byte, perm fs.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package snip
+
+import (
+	"io/fs"
+
+	"github.com/google/renameio/v2"
+)
+
+// writeFileAtomic atomically replaces the file at path with data using
+// renameio.WriteFile, which also syncs the file and its directory.
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	return renameio.WriteFile(path, data, perm)
+}
@@ -0,0 +1,78 @@
+package snip
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// failRename makes atomic writes fail with a rename error wrapping errno for
+// the duration of the test.
+func failRename(t *testing.T, errno syscall.Errno) {
+	t.Helper()
+	previous := atomicWrite
+	atomicWrite = func(path string, data []byte, perm fs.FileMode) error {
+		return &os.LinkError{Op: "rename", Old: path + ".tmp", New: path, Err: errno}
+	}
+	t.Cleanup(func() { atomicWrite = previous })
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "2024-11-20.txt")
+	if err := WriteFile(path, []byte("first\n"), 0o600, false); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := WriteFile(path, []byte("second\n"), 0o600, false); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "second\n" {
+		t.Errorf("after WriteFile, the file contains %q, want %q", got, "second\n")
+	}
+}
+
+func TestWriteFileRenameFailure(t *testing.T) {
+	for _, tt := range []struct {
+		name              string
+		errno             syscall.Errno
+		nonAtomicFallback bool
+		wantWritten       bool
+	}{
+		{name: "unsupported without fallback", errno: syscall.EXDEV},
+		{name: "unsupported with fallback", errno: syscall.EXDEV, nonAtomicFallback: true, wantWritten: true},
+		{name: "not supported with fallback", errno: syscall.ENOTSUP, nonAtomicFallback: true, wantWritten: true},
+		{name: "permission denied with fallback", errno: syscall.EACCES, nonAtomicFallback: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			failRename(t, tt.errno)
+			path := filepath.Join(t.TempDir(), "2024-11-20.txt")
+			if err := os.WriteFile(path, []byte("old\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			err := WriteFile(path, []byte("new\n"), 0o600, tt.nonAtomicFallback)
+			if tt.wantWritten != (err == nil) {
+				t.Fatalf("WriteFile() = %v, want written: %v", err, tt.wantWritten)
+			}
+			if err != nil && !errors.Is(err, tt.errno) {
+				t.Errorf("WriteFile() = %v, want the rename error", err)
+			}
+			want := "old\n"
+			if tt.wantWritten {
+				want = "new\n"
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != want {
+				t.Errorf("after WriteFile, the file contains %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+//go:build windows
+
+package snip
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic atomically replaces the file at path with data, by writing it
+// to a temporary file in the same directory and renaming that over path. The
+// renameio package doesn't support Windows, but os.Rename replaces the target
+// with MoveFileEx, which is atomic on NTFS.
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// runStats implements the "stats" subcommand, which prints statistics about
+// how consistently snippets have been recorded.
+func runStats(args []string) error {
+	fs := newFlagSet("stats")
+	notebookFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	paths, err := snippetFiles()
+	if err != nil {
+		return fmt.Errorf("stats: %w", err)
+	}
+	// The active days, i.e. days with at least one snippet, in chronological
+	// order.
+	var active []time.Time
+	total := 0
+	for _, path := range paths {
+		date, ok := snip.FileDate(path)
+		if !ok {
+			continue
+		}
+		contents, err := readSnippetFile(path)
+		if err != nil {
+			return fmt.Errorf("stats: %w", err)
+		}
+		n := len(snippetLines(contents))
+		if n == 0 {
+			continue
+		}
+		active = append(active, date)
+		total += n
+	}
+
+	// Compute the longest streak, and the streak ending with the last active
+	// day.
+	longest, streak := 0, 0
+	for i, date := range active {
+		if i > 0 && active[i-1].AddDate(0, 0, 1).Equal(date) {
+			streak++
+		} else {
+			streak = 1
+		}
+		longest = max(longest, streak)
+	}
+	// The current streak is the one ending with the last active day, but only
+	// if that's today or yesterday. Counting a streak ending yesterday means
+	// that the streak isn't considered broken just because nothing has been
+	// recorded yet today.
+	current := 0
+	if n := len(active); n != 0 {
+		now := dayOf(clock())
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		if last := active[n-1]; last.Equal(today) || last.Equal(today.AddDate(0, 0, -1)) {
+			current = streak
+		}
+	}
+	average := 0.0
+	if len(active) != 0 {
+		average = float64(total) / float64(len(active))
+	}
+
+	fmt.Printf("Days with snippets: %d\n", len(active))
+	fmt.Printf("Current streak: %d\n", current)
+	fmt.Printf("Longest streak: %d\n", longest)
+	fmt.Printf("Average snippets per active day: %.1f\n", average)
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/saser/snip/snip"
+)
+
+// tagList is a flag.Value that collects the values of a repeated flag, e.g.
+// "-tag work -tag urgent".
+type tagList []string
+
+func (l *tagList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *tagList) Set(value string) error {
+	tag := strings.TrimPrefix(value, "#")
+	if !tagNameRE.MatchString(tag) {
+		return fmt.Errorf("invalid tag %q: tags may only contain letters, digits, '-' and '_'", value)
+	}
+	*l = append(*l, tag)
+	return nil
+}
+
+// tagRE matches a tag like "#work" in snippet text. The tag must be at the
+// start of the text or preceded by whitespace, so that e.g. URL fragments
+// aren't interpreted as tags.
+var tagRE = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_-]+)`)
+
+// tagNameRE matches a valid tag name, without the leading '#'.
+var tagNameRE = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// extractTags returns the names (without the leading '#') of all tags in text,
+// in the order they appear. Code blocks (see [snip.CodeFence]), e.g. from
+// -raw, are skipped, so that e.g. "#include" in code isn't taken for a tag.
+func extractTags(text string) []string {
+	var tags []string
+	inCode := false
+	for _, line := range strings.Split(text, "\n") {
+		if isFenceLine([]byte(line)) {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		for _, m := range tagRE.FindAllStringSubmatch(line, -1) {
+			tags = append(tags, m[1])
+		}
+	}
+	return tags
+}
+
+// appendTags appends the given tags to text as " #tag", skipping any tags
+// that are already present in text.
+func appendTags(text []byte, tags []string) []byte {
+	existing := extractTags(string(text))
+	for _, tag := range tags {
+		if slices.Contains(existing, tag) {
+			continue
+		}
+		text = append(text, " #"+tag...)
+		existing = append(existing, tag)
+	}
+	return text
+}
+
+// runTags implements the "tags" subcommand, which prints all tags used in any
+// snippet together with how many times they've been used and on which dates.
+func runTags(args []string) error {
+	fs := newFlagSet("tags")
+	notebookFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	type tagInfo struct {
+		count int
+		dates []string
+	}
+	infos := make(map[string]*tagInfo)
+	paths, err := snippetFiles()
+	if err != nil {
+		return fmt.Errorf("list tags: %w", err)
+	}
+	for _, path := range paths {
+		contents, err := readSnippetFile(path)
+		if err != nil {
+			return fmt.Errorf("list tags: %w", err)
+		}
+		date := snip.FileName(path)
+		for _, line := range snippetLines(contents) {
+			for _, tag := range extractTags(string(line)) {
+				info, ok := infos[tag]
+				if !ok {
+					info = &tagInfo{}
+					infos[tag] = info
+				}
+				info.count++
+				// The files are sorted chronologically, so we only need to
+				// compare against the last date seen.
+				if n := len(info.dates); n == 0 || info.dates[n-1] != date {
+					info.dates = append(info.dates, date)
+				}
+			}
+		}
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	tags := make([]string, 0, len(infos))
+	for tag := range infos {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+	for _, tag := range tags {
+		info := infos[tag]
+		fmt.Fprintf(w, "#%s (%d): %s\n", tag, info.count, strings.Join(info.dates, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("list tags: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractTags(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "tags",
+			text: "#standup reviewed the MR #work",
+			want: []string{"standup", "work"},
+		},
+		{
+			name: "URL fragment",
+			text: "read https://example.com/#section",
+		},
+		{
+			name: "continuation lines",
+			text: "deployed #work\n  and rolled back #oncall",
+			want: []string{"work", "oncall"},
+		},
+		{
+			name: "code block",
+			text: string(formatRawSnippet([]byte("fixed the build #work\n#!/bin/sh\n#include <stdio.h>\necho #notatag"))),
+			want: []string{"work"},
+		},
+		{
+			name: "after a code block",
+			text: "script #work\n  ```\n  #comment\n  ```\n  #after",
+			want: []string{"work", "after"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractTags(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("extractTags(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/saser/snip/snip"
+)
+
+// templatesDirName is the name of the directory in the base directory where
+// templates are stored.
+const templatesDirName = "templates"
+
+// loadTemplate reads the template with the given name, which is stored as
+// <name>.txt in the templates directory.
+func loadTemplate(name string) ([]byte, error) {
+	if err := snip.CheckFileName(name); err != nil {
+		return nil, fmt.Errorf("load template: %w", err)
+	}
+	base, err := baseDir()
+	if err != nil {
+		return nil, fmt.Errorf("load template: %w", err)
+	}
+	path := filepath.Join(base, templatesDirName, name+".txt")
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("load template: template %q not found; create it as %s", name, path)
+	} else if err != nil {
+		return nil, fmt.Errorf("load template: %w", err)
+	}
+	return contents, nil
+}
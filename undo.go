@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// undoDirName is the name of the directory in the base directory containing
+// the undo logs of the snippet files.
+const undoDirName = ".undo"
+
+// undoLimit is the number of mutations kept in the undo log of each snippet
+// file.
+const undoLimit = 10
+
+// undoEntry is a mutation of a snippet file, as recorded in its undo log.
+type undoEntry struct {
+	// Time is when the mutation happened.
+	Time time.Time `json:"time"`
+	// Previous is the contents of the snippet file before the mutation, as
+	// stored on disk (i.e. encrypted, for encrypted snippet files), or nil if
+	// the file didn't exist.
+	Previous *string `json:"previous"`
+}
+
+// undoLogPath returns the path of the undo log for the snippet file at path,
+// which mirrors the path of the snippet file relative to the base directory,
+// e.g. ".undo/work/2024-11-20.json" for "work/2024-11-20.txt".
+func undoLogPath(path string) (string, error) {
+	base, err := baseDir()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, undoDirName, strings.TrimSuffix(rel, filepath.Ext(rel))+".json"), nil
+}
+
+// readUndoLog reads the undo log at path, oldest mutation first. A missing
+// undo log is treated as empty.
+func readUndoLog(path string) ([]undoEntry, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var entries []undoEntry
+	if err := json.Unmarshal(contents, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// writeUndoLog writes the undo log at path, removing it if there are no
+// entries.
+func writeUndoLog(path string, entries []undoEntry) error {
+	if len(entries) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), fs.FileMode(dirMode)); err != nil {
+		return err
+	}
+	return writeFile(path, append(b, '\n'))
+}
+
+// recordUndo records in the undo log of the snippet file at path that it's
+// about to be changed from previous (nil if it doesn't exist), keeping only
+// the last undoLimit mutations. It must be called with the lock held (see
+// lockSnippets).
+func recordUndo(path string, previous []byte) error {
+	logPath, err := undoLogPath(path)
+	if err != nil {
+		return fmt.Errorf("record undo: %w", err)
+	}
+	entries, err := readUndoLog(logPath)
+	if err != nil {
+		return fmt.Errorf("record undo: %w", err)
+	}
+	e := undoEntry{Time: clock()}
+	if previous != nil {
+		// Encrypted snippet files must not be stored in plaintext in the undo
+		// log.
+		data, err := encodeSnippetFile(path, previous)
+		if err != nil {
+			return fmt.Errorf("record undo: %w", err)
+		}
+		s := string(data)
+		e.Previous = &s
+	}
+	entries = append(entries, e)
+	if n := len(entries); n > undoLimit {
+		entries = entries[n-undoLimit:]
+	}
+	if err := writeUndoLog(logPath, entries); err != nil {
+		return fmt.Errorf("record undo: %w", err)
+	}
+	return nil
+}
+
+// runUndo implements the "undo" subcommand, which reverts the most recent
+// mutation of a snippet file (today's by default), such as adding, editing, or
+// deleting a snippet, by restoring its previous contents.
+func runUndo(args []string) error {
+	flags := newFlagSet("undo")
+	notebookFlag(flags)
+	date := flags.String("date", "", "Date of the snippet file to undo the last change to, in the format YYYY-MM-DD. Defaults to today.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	t := dayOf(clock())
+	if d := *date; d != "" {
+		var err error
+		t, err = parseDateFlag("date", d)
+		if err != nil {
+			return fmt.Errorf("undo: %w", err)
+		}
+	}
+	path, err := snippetPath(t)
+	if err != nil {
+		return fmt.Errorf("undo: %w", err)
+	}
+	logPath, err := undoLogPath(path)
+	if err != nil {
+		return fmt.Errorf("undo: %w", err)
+	}
+	unlock, err := lockSnippets()
+	if err != nil {
+		return fmt.Errorf("undo: %w", err)
+	}
+	defer unlock()
+	entries, err := readUndoLog(logPath)
+	if err != nil {
+		return fmt.Errorf("undo: %w", err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("undo: nothing to undo for %s", path)
+	}
+
+	last := entries[len(entries)-1]
+	if last.Previous == nil {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("undo: %w", err)
+		}
+	} else if err := writeFile(path, []byte(*last.Previous)); err != nil {
+		return fmt.Errorf("undo: %w", err)
+	}
+	if err := writeUndoLog(logPath, entries[:len(entries)-1]); err != nil {
+		return fmt.Errorf("undo: %w", err)
+	}
+	return nil
+}
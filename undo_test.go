@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUndo(t *testing.T) {
+	now := time.Date(2024, time.November, 20, 11, 0, 0, 0, time.Local)
+	setClock(t, now)
+	base := t.TempDir()
+	setFlag(t, "dir", base)
+	path := filepath.Join(base, "2024-11-20.txt")
+	read := func() string {
+		t.Helper()
+		contents, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return "<missing>"
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		return string(contents)
+	}
+
+	// Appending the first snippet creates the file, so undoing it removes the
+	// file again.
+	if _, err := writeSnippets([][]byte{[]byte("first")}); err != nil {
+		t.Fatalf("writeSnippets failed: %v", err)
+	}
+	if err := runUndo(nil); err != nil {
+		t.Fatalf("undo after appending failed: %v", err)
+	}
+	if got := read(); got != "<missing>" {
+		t.Errorf("after appending the first snippet and undoing, the snippet file is:\n%s\nwant it removed", got)
+	}
+
+	if _, err := writeSnippets([][]byte{[]byte("first")}); err != nil {
+		t.Fatalf("writeSnippets failed: %v", err)
+	}
+	appended := read()
+	if _, err := writeSnippets([][]byte{[]byte("second")}); err != nil {
+		t.Fatalf("writeSnippets failed: %v", err)
+	}
+	if err := runUndo(nil); err != nil {
+		t.Fatalf("undo after appending failed: %v", err)
+	}
+	if got := read(); got != appended {
+		t.Errorf("after appending and undoing, the snippet file is:\n%s\nwant:\n%s", got, appended)
+	}
+
+	if err := deleteLastSnippet(); err != nil {
+		t.Fatalf("deleteLastSnippet failed: %v", err)
+	}
+	if err := runUndo(nil); err != nil {
+		t.Fatalf("undo after deleting failed: %v", err)
+	}
+	if got := read(); got != appended {
+		t.Errorf("after deleting and undoing, the snippet file is:\n%s\nwant:\n%s", got, appended)
+	}
+
+	// Undo the append of the first snippet, after which there's nothing left
+	// to undo.
+	if err := runUndo(nil); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+	if err := runUndo(nil); err == nil {
+		t.Error("undo with an empty undo log succeeded, want error")
+	}
+}
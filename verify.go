@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// runVerify implements the "verify" subcommand, which checks all snippet files
+// for anomalies, such as snippets without timestamps or duplicate headers, and
+// prints them. It returns an error if there were any, so that it can be run
+// e.g. from cron.
+func runVerify(args []string) error {
+	fs := newFlagSet("verify")
+	notebookFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	paths, err := snippetFiles()
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	problems := 0
+	for _, path := range paths {
+		contents, err := readSnippetFile(path)
+		if err != nil {
+			return fmt.Errorf("verify: %w", err)
+		}
+		for _, p := range verifyFile(path, contents) {
+			fmt.Fprintln(w, p)
+			problems++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+	if problems != 0 {
+		return fmt.Errorf("verify: found %d problems", problems)
+	}
+	return nil
+}
+
+// verifyFile checks the contents of the snippet file at path, and returns the
+// problems found as "path:line: problem", or "path: problem" for problems with
+// the file as a whole.
+func verifyFile(path string, contents []byte) []string {
+	var problems []string
+	report := func(line int, format string, args ...any) {
+		loc := path
+		if line != 0 {
+			loc = fmt.Sprintf("%s:%d", path, line)
+		}
+		problems = append(problems, loc+": "+fmt.Sprintf(format, args...))
+	}
+
+	name := snip.FileName(path)
+	date, ok := snip.FileDate(path)
+	if !ok {
+		report(0, "file name %q is not a date, week, or month", name)
+	}
+	// Only daily snippet files have the date in the header.
+	isDate := ok && name == date.Format(time.DateOnly)
+	if len(contents) == 0 {
+		report(0, "file is empty")
+		return problems
+	}
+
+	checkTimestamps := *includeTime != "" && !*noTimestamp
+	first := true
+	// A front matter block spans several lines, so it's checked as a whole
+	// and then skipped.
+	frontMatterLines := 0
+	if header, _ := splitHeader(contents); snip.IsFrontMatter(header) {
+		frontMatterLines = bytes.Count(header, []byte{'\n'})
+		first = false
+		if isDate && !bytes.Contains(header, []byte("\ndate: "+name+"\n")) {
+			report(1, "front matter does not contain the date %s in the file name", name)
+		}
+	}
+	for i, line := range strings.Split(string(contents), "\n") {
+		n := i + 1
+		if n <= frontMatterLines {
+			continue
+		}
+		if i == 0 {
+			// Ignore a byte order mark, like [snip.HasHeader] does.
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || snip.IsDivider([]byte(line)) {
+			continue
+		}
+		wasFirst := first
+		first = false
+		// Lines starting with the header prefix but without the marker are
+		// only headers at the top of the file, where they were written before
+		// the marker was introduced. Elsewhere, they are snippets.
+		header, marked := strings.CutPrefix(strings.TrimSpace(line), snip.HeaderMarker)
+		if marked || wasFirst && strings.HasPrefix(header, *headerPrefix) {
+			if !wasFirst {
+				report(n, "unexpected header line; the header must be the first line, and there must only be one")
+				continue
+			}
+			if isDate && !headerMatchesDate(header, date) {
+				report(n, "header %q does not match the date %s in the file name", header, name)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, snip.ContinuationIndent) || !checkTimestamps {
+			continue
+		}
+		prefix, _, ok := splitTimestamp(line)
+		if !ok {
+			report(n, "snippet has no timestamp")
+			continue
+		}
+		if _, err := parseTimestamp(name, prefix); err != nil {
+			report(n, "invalid timestamp %q: %v", prefix, err)
+		}
+	}
+	return problems
+}
+
+// headerMatchesDate reports whether header is a header formatted according to
+// -header_format for the given date, with any timezone.
+func headerMatchesDate(header string, date time.Time) bool {
+	parts := strings.Split(date.Format(*headerFormat), "%tz")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	ok, _ := regexp.MatchString("^"+strings.Join(parts, ".*")+"$", strings.TrimSpace(header))
+	return ok
+}
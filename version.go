@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// version is the version of snip. It can be set when building, e.g. with
+// -ldflags "-X main.version=v1.2.3"; otherwise, the module version from the
+// build info is used.
+var version string
+
+// versionString describes the version of snip, including the VCS revision and
+// commit time if they're available from the build info, e.g.
+// "snip v1.2.3 (revision 0123abc, committed 2024-11-20T09:30:00Z)".
+func versionString() string {
+	v := version
+	var details []string
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "" {
+			v = info.Main.Version
+		}
+		settings := make(map[string]string)
+		for _, s := range info.Settings {
+			settings[s.Key] = s.Value
+		}
+		if rev := settings["vcs.revision"]; rev != "" {
+			details = append(details, "revision "+rev)
+		}
+		if t := settings["vcs.time"]; t != "" {
+			details = append(details, "committed "+t)
+		}
+		if settings["vcs.modified"] == "true" {
+			details = append(details, "with local modifications")
+		}
+	}
+	if v == "" {
+		v = "(unknown version)"
+	}
+	s := "snip " + v
+	if len(details) != 0 {
+		s += fmt.Sprintf(" (%s)", strings.Join(details, ", "))
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// runWatch implements the "watch" subcommand, which prints today's snippets
+// and then keeps printing new snippets as they're added, e.g. by other
+// invocations of snip, until interrupted.
+func runWatch(args []string) error {
+	flags := newFlagSet("watch")
+	notebookFlag(flags)
+	interval := flags.Duration("interval", time.Second, "How often to check the snippet file for new snippets.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return usageErrorf("watch: invalid -interval %v: must be positive", *interval)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	// The snippet file is polled rather than watched with e.g. inotify, which
+	// keeps this portable and is cheap enough for a single small file. The
+	// path is resolved on every poll, so that watching continues in the next
+	// day's file after midnight (or -day_start).
+	var path string
+	printed := 0
+	for {
+		p, err := snippetPath(dayOf(clock()))
+		if err != nil {
+			return fmt.Errorf("watch: %w", err)
+		}
+		if p != path {
+			path, printed = p, 0
+		}
+		contents, err := readSnippetFile(path)
+		// The file not existing yet just means that there are no snippets
+		// yet today.
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("watch: %w", err)
+		}
+		lines := snippetLines(contents)
+		// If snippets were removed, e.g. with -delete_last, there's nothing
+		// new to print until more snippets are added.
+		printed = min(printed, len(lines))
+		for _, line := range lines[printed:] {
+			if _, err := fmt.Printf("%s\n", line); err != nil {
+				return fmt.Errorf("watch: %w", err)
+			}
+		}
+		printed = len(lines)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
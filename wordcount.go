@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/saser/snip/snip"
+)
+
+// wordCountRE matches the annotation added by -word_count at the end of a
+// snippet, e.g. " (42 words)".
+var wordCountRE = regexp.MustCompile(` \(\d+ words?\)$`)
+
+// countWords returns the number of words in the snippet text, not counting the
+// annotation added by -word_count, the author added by -attribute, or the
+// marker added by -priority, if any.
+func countWords(text string) int {
+	if p := extractPriority(text); p != "" {
+		marker, _ := priorityMarker(p)
+		text = strings.TrimPrefix(text, marker)
+	}
+	text = wordCountRE.ReplaceAllString(text, "")
+	text = authorRE.ReplaceAllString(text, "")
+	return len(strings.Fields(text))
+}
+
+// appendWordCount appends an annotation with the number of words in text to
+// it, e.g. " (42 words)".
+func appendWordCount(text []byte) []byte {
+	n := countWords(string(text))
+	unit := "words"
+	if n == 1 {
+		unit = "word"
+	}
+	return fmt.Appendf(text, " (%d %s)", n, unit)
+}
+
+// runWordcount implements the "wordcount" subcommand, which prints the number
+// of words written per day in a date range, and the total.
+func runWordcount(args []string) error {
+	fs := newFlagSet("wordcount")
+	notebookFlag(fs)
+	since := fs.String("since", "", "First date (YYYY-MM-DD, inclusive) to count words for. Defaults to the first date with snippets.")
+	until := fs.String("until", "", "Last date (YYYY-MM-DD, inclusive) to count words for. Defaults to the last date with snippets.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	r, err := parseDateRange(*since, *until)
+	if err != nil {
+		return fmt.Errorf("count words: %w", err)
+	}
+
+	paths, err := snippetFiles()
+	if err != nil {
+		return fmt.Errorf("count words: %w", err)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	total := 0
+	for _, path := range paths {
+		date, ok := snip.FileDate(path)
+		if !ok || !r.contains(date) {
+			continue
+		}
+		contents, err := readSnippetFile(path)
+		if err != nil {
+			return fmt.Errorf("count words: %w", err)
+		}
+		name := snip.FileName(path)
+		n := 0
+		for _, line := range snippetLines(contents) {
+			n += countWords(parseSnippetLine(name, string(line)).Text)
+		}
+		total += n
+		fmt.Fprintf(w, "%s: %d\n", date.Format(time.DateOnly), n)
+	}
+	fmt.Fprintf(w, "total: %d\n", total)
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("count words: %w", err)
+	}
+	return nil
+}
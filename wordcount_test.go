@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestCountWords(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want int
+	}{
+		{text: "reviewed the MR", want: 3},
+		{text: "reviewed the MR (3 words)", want: 3},
+		{text: "reviewed the MR @alice", want: 3},
+		{text: "reviewed the MR @alice (3 words)", want: 3},
+		{text: "[!] prod is down", want: 3},
+		{text: "[!] prod is down @alice (3 words)", want: 3},
+		{text: "emailed bob@example.com", want: 2},
+		{text: "lunch (1 word)", want: 1},
+	} {
+		if got := countWords(tt.text); got != tt.want {
+			t.Errorf("countWords(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAppendWordCount(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want string
+	}{
+		{text: "lunch", want: "lunch (1 word)"},
+		{text: "reviewed the MR @alice", want: "reviewed the MR @alice (3 words)"},
+		{text: "[~] tidy the backlog", want: "[~] tidy the backlog (3 words)"},
+	} {
+		if got := string(appendWordCount([]byte(tt.text))); got != tt.want {
+			t.Errorf("appendWordCount(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}